@@ -1,6 +1,60 @@
 package main
 
 type Config struct {
-	Input  string
-	Output string
+	Input     string
+	Output    string
+	Resources []string
+	Packages  []string
+	Cache     string
+	Profiles  []string
+	// PrimitiveExtensions is the strategy of the primitive extension properties: drop, keep or fold.
+	PrimitiveExtensions string
+	// ElementMetadata annotates the properties with the FHIR element metadata.
+	ElementMetadata bool
+	// ReferenceTypes enables the reference components narrowed to the target resource types.
+	ReferenceTypes bool
+	// Examples are the directories or packages of the example resources.
+	Examples []string
+	// MaxExamples limits the number of the named examples of the bodies.
+	MaxExamples int
+	// Naming is the strategy of the naming of the components: keep or pascal.
+	Naming string
+	// NamePrefix and NameSuffix are added to the component names.
+	NamePrefix, NameSuffix string
+	// NameMapping is the YAML or JSON file with the mapping of the definition names to the component names.
+	NameMapping string
+	// ReportCycles warns about the reference cycles.
+	ReportCycles bool
+	// BreakCycles makes the required properties of the reference cycles nullable.
+	BreakCycles bool
+	// PrimitiveMapping is the mode of the mapping of the primitive types: lossy or faithful.
+	PrimitiveMapping string
+	// TypeMapping is the YAML or JSON file with the mapping of the primitive types.
+	TypeMapping string
+	// DecimalAsString maps decimals to the patterned strings.
+	DecimalAsString bool
+	// ChoiceConstraints constrains the choice elements to have at most one variant.
+	ChoiceConstraints bool
+	// FHIRVersion is the FHIR version, it is detected by the input if it is empty.
+	FHIRVersion string
+	// Include are the names or glob patterns of the selected resources.
+	Include []string
+	// Exclude are the names or glob patterns of the excluded resources.
+	Exclude []string
+	// Categories are the categories of the selected resources.
+	Categories []string
+	// MergePatch adds JSON Merge Patch to the patch request bodies.
+	MergePatch bool
+	// SMART adds the SMART App Launch configuration endpoint.
+	SMART bool
+	// UDAP adds the UDAP server metadata endpoint.
+	UDAP bool
+	// Strict generates exactly the FHIR RESTful API.
+	Strict bool
+	// Capability is the CapabilityStatement file the generated API is restricted to.
+	Capability string
+	// ProfileBodies enables using the profiles as the request and response bodies.
+	ProfileBodies bool
+	// Verbose adds the detailed warnings.
+	Verbose bool
 }
@@ -1,11 +1,58 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"strings"
+
+	"github.com/gotidy/fhir-to-openapi/pkg/generator"
+)
+
+// stringsFlag is a flag that can be set multiple times.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
 
 func ParseFlags() Config {
 	config := Config{}
 	flag.StringVar(&(config.Output), "o", "", "Output file, else output to STDOUT")
-	flag.StringVar(&(config.Input), "i", "", "Input file, else get from STDIN")
+	flag.StringVar(&(config.Input), "i", "", "Input JSON schema file, else get from STDIN if no FHIR resources or packages are given")
+	flag.Var((*stringsFlag)(&config.Resources), "r", "FHIR resource or Bundle file, e.g. search-parameters.json, profiles-resources.json, valuesets.json, operations.json or compartmentdefinition-patient.json (can be repeated)")
+	flag.Var((*stringsFlag)(&config.Packages), "p", "FHIR package: .tgz file, package directory or id#version from the package cache (can be repeated)")
+	flag.StringVar(&(config.Cache), "cache", generator.DefaultPackageCache(), "FHIR package cache directory")
+	flag.Var((*stringsFlag)(&config.Profiles), "profile", "Canonical URL or name of the profile to generate the component for, \"*\" for all loaded profiles (can be repeated)")
+	flag.BoolVar(&(config.ProfileBodies), "profile-bodies", false, "Use the profiles as the request and response bodies of the constrained resources")
+	flag.StringVar(&(config.Capability), "capability", "", "CapabilityStatement file, the generated API is restricted to the declared resources, interactions, search parameters and operations")
+	flag.BoolVar(&(config.MergePatch), "merge-patch", false, "Accept JSON Merge Patch (application/merge-patch+json) besides JSON Patch and FHIRPath Patch in the patch interactions")
+	flag.BoolVar(&(config.SMART), "smart", true, "Generate the SMART App Launch configuration endpoint /.well-known/smart-configuration, -smart=false omits it")
+	flag.BoolVar(&(config.UDAP), "udap", false, "Generate the UDAP server metadata endpoint /.well-known/udap")
+	flag.BoolVar(&(config.Strict), "strict", false, "Generate exactly the FHIR RESTful API: resource paths only, vread, history, POST _search, conditional interactions and their headers, interaction status codes")
+	flag.StringVar(&(config.FHIRVersion), "fhir-version", "", "FHIR version: STU3, R4, R4B or R5, else detected by the input")
+	flag.StringVar(&(config.PrimitiveExtensions), "primitive-extensions", string(generator.PrimitiveExtensionsDrop), "Primitive extension properties, e.g. _birthDate: drop, keep as siblings or fold into the x-fhir-primitive-extension annotation; the closed objects stay open unless they are kept")
+	flag.BoolVar(&(config.ChoiceConstraints), "choice-constraints", false, "Constrain the choice elements, e.g. value[x], to have at most one variant with oneOf")
+	flag.BoolVar(&(config.ReferenceTypes), "reference-types", false, "Generate the reference components narrowed to the target resource types, e.g. Reference_Patient")
+	flag.Var((*stringsFlag)(&config.Examples), "examples", "Directory of the example resources, e.g. examples-json, or FHIR package with the example folder: .tgz file, package directory or id#version (can be repeated)")
+	flag.IntVar(&(config.MaxExamples), "max-examples", 3, "Maximum number of the named examples of the request and response bodies, 0 for no limit")
+	flag.StringVar(&(config.Naming), "naming", string(generator.NamingKeep), "Naming of the components: keep the definition names or join their parts in PascalCase (pascal), e.g. Patient_Contact to PatientContact")
+	flag.StringVar(&(config.NamePrefix), "name-prefix", "", "Prefix of the component names")
+	flag.StringVar(&(config.NameSuffix), "name-suffix", "", "Suffix of the component names")
+	flag.StringVar(&(config.NameMapping), "names", "", "YAML or JSON file mapping the definition names to the component names, e.g. {Bundle_Entry: BundleEntry}")
+	flag.BoolVar(&(config.ReportCycles), "report-cycles", false, "Warn about the reference cycles of the components")
+	flag.BoolVar(&(config.BreakCycles), "break-cycles", false, "Make the required properties of the reference cycles nullable, so the generated code uses pointers for them; allOf members can not be made nullable, so the cycles of allOf compositions alone are reported, not broken")
+	flag.StringVar(&(config.PrimitiveMapping), "primitive-mapping", string(generator.LossyMapping), "Mapping of the primitive types: lossy (OpenAPI formats) or faithful (FHIR patterns, partial dates)")
+	flag.BoolVar(&(config.DecimalAsString), "decimal-as-string", false, "Map decimals to the patterned strings to keep their precision")
+	flag.StringVar(&(config.TypeMapping), "mapping", "", "YAML or JSON file overriding the mapping of the primitive types, e.g. {instant: {type: string, format: date-time, x-go-type: time.Time}}")
+	flag.BoolVar(&(config.ElementMetadata), "element-metadata", false, "Annotate the properties built from the structure definitions with the FHIR element metadata: x-fhir-path, x-fhir-min, x-fhir-max, etc.")
+	flag.Var((*stringsFlag)(&config.Include), "include", "Name or glob pattern of the resource to generate with its dependencies, e.g. Patient or Medication* (can be repeated)")
+	flag.Var((*stringsFlag)(&config.Exclude), "exclude", "Name or glob pattern of the resource to exclude (can be repeated)")
+	flag.Var((*stringsFlag)(&config.Categories), "category", "Category of the resources to generate, e.g. Clinical or Clinical.Diagnostics, requires the structure definitions (can be repeated)")
+	flag.BoolVar(&(config.Verbose), "verbose", false, "Warn in detail, e.g. list the definitions left open since their primitive extension properties are not generated")
 	flag.Parse()
 	return config
 }
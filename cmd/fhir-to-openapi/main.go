@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,14 +16,18 @@ func main() {
 
 	config := ParseFlags()
 
-	input := os.Stdin
-	if config.Input != "" {
-		var err error
-		input, err = os.Open(config.Input)
+	// The JSON schema is optional if FHIR resources or packages are given, they can contain structure definitions.
+	var input io.Reader
+	switch {
+	case config.Input != "":
+		f, err := os.Open(config.Input)
 		if err != nil {
 			log.Fatal().Msgf("Opening file «%s»: %s", config.Input, err)
 		}
-		defer input.Close()
+		defer f.Close()
+		input = f
+	case len(config.Resources) == 0 && len(config.Packages) == 0:
+		input = os.Stdin
 	}
 	output := os.Stdout
 	if config.Output != "" {
@@ -39,9 +44,122 @@ func main() {
 		format = generator.YAML
 	}
 
-	if err := generator.New().Do(input, output, format); err != nil {
+	g := generator.New()
+	g.PackageCache = config.Cache
+	strategy, err := generator.ParsePrimitiveExtensionStrategy(config.PrimitiveExtensions)
+	if err != nil {
+		log.Fatal().Msgf("Parsing primitive extension strategy: %s", err)
+	}
+	g.PrimitiveExtensions = strategy
+	mapping, err := generator.ParsePrimitiveMapping(config.PrimitiveMapping)
+	if err != nil {
+		log.Fatal().Msgf("Parsing primitive mapping: %s", err)
+	}
+	g.PrimitiveMapping = mapping
+	g.DecimalAsString = config.DecimalAsString
+	naming, err := generator.ParseNamingStrategy(config.Naming)
+	if err != nil {
+		log.Fatal().Msgf("Parsing naming strategy: %s", err)
+	}
+	g.Naming = naming
+	g.NamePrefix = config.NamePrefix
+	g.NameSuffix = config.NameSuffix
+	g.ChoiceConstraints = config.ChoiceConstraints
+	g.ReferenceTypes = config.ReferenceTypes
+	g.ElementMetadata = config.ElementMetadata
+	g.ReportCycles = config.ReportCycles
+	g.BreakCycles = config.BreakCycles
+	g.Strict = config.Strict
+	g.MergePatch = config.MergePatch
+	g.SMART = config.SMART
+	g.UDAP = config.UDAP
+	g.Include = config.Include
+	g.Exclude = config.Exclude
+	g.Categories = config.Categories
+	g.Verbose = config.Verbose
+	if config.FHIRVersion != "" {
+		version, err := generator.ParseFHIRVersion(config.FHIRVersion)
+		if err != nil {
+			log.Fatal().Msgf("Parsing FHIR version: %s", err)
+		}
+		g.Version = version
+	}
+	g.Profiles = config.Profiles
+	g.ProfileBodies = config.ProfileBodies
+	for _, pkg := range config.Packages {
+		if err := g.LoadPackage(pkg); err != nil {
+			log.Fatal().Msgf("Loading package «%s»: %s", pkg, err)
+		}
+	}
+	for _, name := range config.Resources {
+		if err := loadResources(g, name); err != nil {
+			log.Fatal().Msgf("Loading resources «%s»: %s", name, err)
+		}
+	}
+	g.MaxExamples = config.MaxExamples
+	for _, src := range config.Examples {
+		if err := g.LoadExamples(src); err != nil {
+			log.Fatal().Msgf("Loading examples «%s»: %s", src, err)
+		}
+	}
+	if config.TypeMapping != "" {
+		if err := loadTypeMapping(g, config.TypeMapping); err != nil {
+			log.Fatal().Msgf("Loading type mapping «%s»: %s", config.TypeMapping, err)
+		}
+	}
+	if config.NameMapping != "" {
+		if err := loadNameMapping(g, config.NameMapping); err != nil {
+			log.Fatal().Msgf("Loading name mapping «%s»: %s", config.NameMapping, err)
+		}
+	}
+	if config.Capability != "" {
+		if err := loadCapabilityStatement(g, config.Capability); err != nil {
+			log.Fatal().Msgf("Loading capability statement «%s»: %s", config.Capability, err)
+		}
+	}
+
+	if err := g.Do(input, output, format); err != nil {
 		log.Fatal().Msgf("Generation OpenAPI: %s", err)
 	}
+	for _, warning := range g.Warnings {
+		log.Warn().Msg(warning)
+	}
 
 	log.Info().Msg("Successfully generated.")
 }
+
+func loadResources(g *generator.Generator, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.LoadResources(f)
+}
+
+func loadCapabilityStatement(g *generator.Generator, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.LoadCapabilityStatement(f)
+}
+
+func loadTypeMapping(g *generator.Generator, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.LoadTypeMapping(f)
+}
+
+func loadNameMapping(g *generator.Generator, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.LoadNameMapping(f)
+}
@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	bundleName              = "Bundle"
+	bundleTypeProperty      = "type"
+	bundleEntryProperty     = "entry"
+	bundleRequestProperty   = "request"
+	bundleResponseProperty  = "response"
+	bundleRequestEntryName  = "Bundle_RequestEntry"
+	bundleResponseEntryName = "Bundle_ResponseEntry"
+)
+
+// systemBundle is the Bundle of the batch or transaction interaction.
+type systemBundle struct {
+	name        string
+	bundleType  string
+	entry       string
+	interaction string
+	response    bool
+}
+
+// systemBundles are the Bundles of the batch and transaction interactions and their responses.
+var systemBundles = []systemBundle{
+	{name: "TransactionBundle", bundleType: "transaction", entry: bundleRequestEntryName, interaction: InteractionTransaction},
+	{name: "BatchBundle", bundleType: "batch", entry: bundleRequestEntryName, interaction: InteractionBatch},
+	{name: "TransactionResponseBundle", bundleType: "transaction-response", entry: bundleResponseEntryName, interaction: InteractionTransaction, response: true},
+	{name: "BatchResponseBundle", bundleType: "batch-response", entry: bundleResponseEntryName, interaction: InteractionBatch, response: true},
+}
+
+// isSystemBundle checks that the definition is the Bundle of the batch or transaction interaction or its entry.
+func isSystemBundle(name string) bool {
+	if name == bundleRequestEntryName || name == bundleResponseEntryName {
+		return true
+	}
+	for _, b := range systemBundles {
+		if b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addBundleDefinitions adds the definitions of the Bundles of the supported batch and transaction interactions.
+// They are the copies of Bundle with the fixed type and the entries requiring the request or the response.
+func (g *Generator) addBundleDefinitions() {
+	defs := g.Schema.Definitions
+	bundle, ok := defs[bundleName]
+	if !ok {
+		return
+	}
+	entry := bundle.Properties[bundleEntryProperty]
+	if entry == nil || entry.Items == nil || !strings.HasPrefix(entry.Items.Ref, definitionsPrefix) {
+		return
+	}
+	entryDef := defs[strings.TrimPrefix(entry.Items.Ref, definitionsPrefix)]
+	if entryDef == nil || entryDef.Properties[bundleRequestProperty] == nil || entryDef.Properties[bundleResponseProperty] == nil {
+		return
+	}
+
+	for _, b := range systemBundles {
+		if !g.supports("", b.interaction) {
+			continue
+		}
+		def := bundle.clone()
+		description := "Bundle of the " + b.bundleType + "."
+		def.Description = description
+		def.Properties[bundleTypeProperty] = &Type{Type: "string", Enum: []interface{}{b.bundleType}}
+		def.Properties[bundleEntryProperty] = &Type{Type: "array", Description: entry.Description, Items: &Type{Ref: definitionsPrefix + b.entry}}
+		def.Required = appendRequired(def.Required, bundleTypeProperty)
+		defs[b.name] = def
+
+		if _, ok := defs[b.entry]; !ok {
+			entryCopy := entryDef.clone()
+			property := bundleRequestProperty
+			if b.response {
+				property = bundleResponseProperty
+			}
+			entryCopy.Required = appendRequired(entryCopy.Required, property)
+			defs[b.entry] = entryCopy
+		}
+	}
+}
+
+// systemBundleSchema returns the schema of the request or the response Bundles of the supported batch and transaction interactions.
+// The Bundles are dispatched by the type. It returns nil if there are no Bundle definitions.
+func (g *Generator) systemBundleSchema(response bool) *openapi3.SchemaRef {
+	var refs openapi3.SchemaRefs
+	mapping := make(map[string]string)
+	for _, b := range systemBundles {
+		if _, ok := g.Schema.Definitions[b.name]; !ok || b.response != response {
+			continue
+		}
+		ref := g.schemaRef(b.name)
+		refs = append(refs, ref)
+		mapping[b.bundleType] = ref.Ref
+	}
+	switch len(refs) {
+	case 0:
+		return nil
+	case 1:
+		return refs[0]
+	}
+	return openapi3.NewSchemaRef("", &openapi3.Schema{
+		OneOf:         refs,
+		Discriminator: &openapi3.Discriminator{PropertyName: bundleTypeProperty, Mapping: mapping},
+	})
+}
+
+// appendRequired adds the property to the required properties if it is missing.
+func appendRequired(required []string, property string) []string {
+	for _, name := range required {
+		if name == property {
+			return required
+		}
+	}
+	return append(required, property)
+}
@@ -0,0 +1,308 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RESTful interactions.
+const (
+	InteractionRead            = "read"
+	InteractionVRead           = "vread"
+	InteractionUpdate          = "update"
+	InteractionPatch           = "patch"
+	InteractionDelete          = "delete"
+	InteractionHistoryInstance = "history-instance"
+	InteractionHistoryType     = "history-type"
+	InteractionCreate          = "create"
+	InteractionSearchType      = "search-type"
+	InteractionTransaction     = "transaction"
+	InteractionBatch           = "batch"
+	InteractionSearchSystem    = "search-system"
+	InteractionHistorySystem   = "history-system"
+	// R5 interactions.
+	InteractionDeleteHistory        = "delete-history"
+	InteractionDeleteHistoryVersion = "delete-history-version"
+)
+
+// Resource versioning support.
+const (
+	VersioningNoVersion       = "no-version"
+	VersioningVersioned       = "versioned"
+	VersioningVersionedUpdate = "versioned-update"
+)
+
+// Conditional read and delete support.
+const (
+	ConditionalReadModifiedSince = "modified-since"
+	ConditionalReadNotMatch      = "not-match"
+	ConditionalReadFullSupport   = "full-support"
+	ConditionalDeleteSingle      = "single"
+	ConditionalDeleteMultiple    = "multiple"
+)
+
+const restModeServer = "server"
+
+// Interactions generated without a capability statement.
+var defaultInteractions = map[string]bool{
+	InteractionRead:         true,
+	InteractionUpdate:       true,
+	InteractionPatch:        true,
+	InteractionDelete:       true,
+	InteractionCreate:       true,
+	InteractionSearchType:   true,
+	InteractionTransaction:  true,
+	InteractionBatch:        true,
+	InteractionSearchSystem: true,
+}
+
+// LoadCapabilityStatement reads the capability statement of the server.
+// The generated API is restricted to the resources, interactions, search parameters and operations it declares.
+func (g *Generator) LoadCapabilityStatement(r io.Reader) error {
+	var cs CapabilityStatement
+	if err := json.NewDecoder(r).Decode(&cs); err != nil {
+		return fmt.Errorf("decoding capability statement: %w", err)
+	}
+	if cs.ResourceType != "CapabilityStatement" {
+		return fmt.Errorf("decoding capability statement: unexpected resource type «%s»", cs.ResourceType)
+	}
+	g.Capability = &cs
+	return nil
+}
+
+// serverRest returns the server RESTful capabilities of the capability statement.
+func (g *Generator) serverRest() *CapabilityStatementRest {
+	if g.Capability == nil {
+		return nil
+	}
+	for i, rest := range g.Capability.Rest {
+		if rest.Mode == restModeServer {
+			return &g.Capability.Rest[i]
+		}
+	}
+	return nil
+}
+
+// capabilityResource returns the declaration of the resource in the capability statement.
+func (g *Generator) capabilityResource(entity string) *CapabilityStatementResource {
+	rest := g.serverRest()
+	if rest == nil {
+		return nil
+	}
+	for i, res := range rest.Resource {
+		if res.Type == entity {
+			return &rest.Resource[i]
+		}
+	}
+	return nil
+}
+
+// isDeclared checks that the resource is declared by the capability statement.
+// All entities are declared if there is no capability statement.
+func (g *Generator) isDeclared(entity string) bool {
+	return g.Capability == nil || g.capabilityResource(entity) != nil
+}
+
+// supports checks that the interaction is supported for the entity, the system interactions are checked if the entity is empty.
+// The default interactions are supported if there is no capability statement.
+func (g *Generator) supports(entity, interaction string) bool {
+	if !g.since(versionInteractions[interaction]) {
+		return false
+	}
+	if g.Capability == nil {
+		if g.Strict {
+			return strictInteractions[interaction]
+		}
+		return defaultInteractions[interaction]
+	}
+	var interactions []CapabilityStatementInteraction
+	if entity == "" {
+		if rest := g.serverRest(); rest != nil {
+			interactions = rest.Interaction
+		}
+	} else if res := g.capabilityResource(entity); res != nil {
+		interactions = res.Interaction
+	}
+	for _, i := range interactions {
+		if i.Code == interaction {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsConditionalUpdate checks that the update of the entity found by the search criteria is supported.
+// It is supported if there is no capability statement, as the type level update was always generated.
+func (g *Generator) supportsConditionalUpdate(entity string) bool {
+	if g.Capability == nil {
+		return true
+	}
+	res := g.capabilityResource(entity)
+	return res != nil && res.ConditionalUpdate && g.supports(entity, InteractionUpdate)
+}
+
+// supportsConditionalDelete checks that the deletion of the entities found by the search criteria is supported.
+func (g *Generator) supportsConditionalDelete(entity string) bool {
+	if g.isStrictDefault() {
+		return true
+	}
+	res := g.capabilityResource(entity)
+	if res == nil || !g.supports(entity, InteractionDelete) {
+		return false
+	}
+	return res.ConditionalDelete == ConditionalDeleteSingle || res.ConditionalDelete == ConditionalDeleteMultiple
+}
+
+// conditionalCreateParameters returns the header parameters of the conditional create interaction if it is supported.
+func (g *Generator) conditionalCreateParameters(entity string) openapi3.Parameters {
+	if res := g.capabilityResource(entity); res != nil && res.ConditionalCreate || g.isStrictDefault() {
+		return openapi3.Parameters{NewParameterWithSchema(InHeader, "If-None-Exist", false, NewSchemaString())}
+	}
+	return nil
+}
+
+// conditionalReadParameters returns the header parameters of the conditional read interaction if it is supported.
+func (g *Generator) conditionalReadParameters(entity string) openapi3.Parameters {
+	res := g.capabilityResource(entity)
+	if g.isStrictDefault() {
+		res = &CapabilityStatementResource{ConditionalRead: ConditionalReadFullSupport}
+	}
+	if res == nil {
+		return nil
+	}
+	var params openapi3.Parameters
+	if res.ConditionalRead == ConditionalReadModifiedSince || res.ConditionalRead == ConditionalReadFullSupport {
+		params = append(params, NewParameterWithSchema(InHeader, "If-Modified-Since", false, NewSchemaString()))
+	}
+	if res.ConditionalRead == ConditionalReadNotMatch || res.ConditionalRead == ConditionalReadFullSupport {
+		params = append(params, NewParameterWithSchema(InHeader, "If-None-Match", false, NewSchemaString()))
+	}
+	return params
+}
+
+// versionedUpdateParameters returns the header parameters of the version aware update if it is supported.
+func (g *Generator) versionedUpdateParameters(entity string) openapi3.Parameters {
+	if res := g.capabilityResource(entity); res != nil && res.Versioning == VersioningVersionedUpdate || g.isStrictDefault() {
+		return openapi3.Parameters{NewParameterWithSchema(InHeader, "If-Match", false, NewSchemaString())}
+	}
+	return nil
+}
+
+// addCapabilitySearchParameters registers the declared search parameters that are not loaded.
+func (g *Generator) addCapabilitySearchParameters() {
+	rest := g.serverRest()
+	if rest == nil {
+		return
+	}
+	for _, sp := range rest.SearchParam {
+		if g.findSearchParameter("", sp.Name) == nil {
+			g.AddSearchParameter(capabilitySearchParameter(sp, BaseResource))
+		}
+	}
+	for _, res := range rest.Resource {
+		for _, sp := range res.SearchParam {
+			if g.findSearchParameter(res.Type, sp.Name) == nil {
+				g.AddSearchParameter(capabilitySearchParameter(sp, res.Type))
+			}
+		}
+	}
+}
+
+func capabilitySearchParameter(sp CapabilityStatementSearchParameter, base string) *SearchParameter {
+	return &SearchParameter{
+		ResourceType: "SearchParameter",
+		URL:          string(sp.Definition),
+		Code:         sp.Name,
+		Base:         []string{base},
+		Type:         sp.Type,
+		Description:  sp.Documentation,
+	}
+}
+
+// findSearchParameter returns the search parameter of the entity or the common search parameter with the code.
+func (g *Generator) findSearchParameter(entity, code string) *SearchParameter {
+	for _, base := range []string{entity, BaseResource, BaseDomainResource} {
+		for _, param := range g.SearchParameters[base] {
+			if param.Code == code {
+				return param
+			}
+		}
+	}
+	return nil
+}
+
+// declaredSearchParameters returns the codes of the search parameters declared for the entity,
+// the system search parameters are returned if the entity is empty. It returns nil if there is no capability statement.
+func (g *Generator) declaredSearchParameters(entity string) map[string]bool {
+	rest := g.serverRest()
+	if rest == nil {
+		return nil
+	}
+	declared := make(map[string]bool)
+	for _, sp := range rest.SearchParam {
+		declared[sp.Name] = true
+	}
+	if res := g.capabilityResource(entity); res != nil {
+		for _, sp := range res.SearchParam {
+			declared[sp.Name] = true
+		}
+	}
+	return declared
+}
+
+// createDeclaredOperationPathes creates the paths of the operations declared by the capability statement.
+// Operations without loaded definitions are generated as POST operations with the Parameters input and output.
+func (g *Generator) createDeclaredOperationPathes(rest *CapabilityStatementRest) {
+	for _, decl := range rest.Operation {
+		op := g.declaredOperation(decl, "")
+		g.Swagger.Paths["/"+operationPrefix+op.Code] = g.operationPathItem(op, "", false)
+	}
+	for _, res := range rest.Resource {
+		if !g.isResource(res.Type) || !g.isSelected(res.Type) {
+			continue
+		}
+		for _, decl := range res.Operation {
+			op := g.declaredOperation(decl, res.Type)
+			if op.Type {
+				g.Swagger.Paths["/"+res.Type+"/"+operationPrefix+op.Code] = g.operationPathItem(op, res.Type, false)
+			}
+			if op.Instance {
+				g.Swagger.Paths["/"+res.Type+"/{id}/"+operationPrefix+op.Code] = g.operationPathItem(op, res.Type, true)
+			}
+		}
+	}
+}
+
+// declaredOperation returns the definition of the declared operation.
+// The definition is looked up by the canonical URL, then by the code and the resource.
+func (g *Generator) declaredOperation(decl CapabilityStatementOperation, entity string) *OperationDefinition {
+	if op, ok := g.OperationDefinitions[canonicalURL(string(decl.Definition))]; ok {
+		return op
+	}
+
+	code := strings.TrimPrefix(decl.Name, operationPrefix)
+	for _, op := range g.operationDefinitions() {
+		if op.Code != code {
+			continue
+		}
+		if entity == "" && op.System {
+			return op
+		}
+		for _, res := range op.Resource {
+			if res == entity || res == BaseResource || res == BaseDomainResource {
+				return op
+			}
+		}
+	}
+	return &OperationDefinition{
+		Code:         code,
+		Description:  decl.Documentation,
+		AffectsState: true,
+		System:       entity == "",
+		Type:         entity != "",
+	}
+}
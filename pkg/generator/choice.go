@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// choiceExt is the extension with the variant properties of the choice elements by the element names.
+const choiceExt = "x-fhir-choice"
+
+// annotateChoices annotates the definitions with the choice groups.
+// If the choice constraints are enabled, the definitions are constrained to have at most one variant of each choice
+// declared by the element definitions, exactly one if the choice is required. The choices recognized by the naming
+// convention are only annotated since unrelated elements can follow it, e.g. reasonCode and reasonReference.
+// maxProperties can not express the constraint since it counts all the properties of the object.
+func (g *Generator) annotateChoices() {
+	for _, def := range g.Schema.Definitions {
+		groups, required, declared := g.choiceGroups(def)
+		if len(groups) == 0 {
+			continue
+		}
+		def.Extras = setExtra(def.Extras, choiceExt, groups)
+		if !g.ChoiceConstraints {
+			continue
+		}
+
+		names := make([]string, 0, len(groups))
+		for name := range groups {
+			if declared[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			def.AllOf = append(def.AllOf, choiceConstraint(groups[name], required[name]))
+		}
+	}
+}
+
+// choiceConstraint returns the constraint allowing one of the variants, or none if the choice is optional.
+func choiceConstraint(variants []string, required bool) *Type {
+	c := &Type{}
+	none := &Type{}
+	for _, v := range variants {
+		c.OneOf = append(c.OneOf, &Type{Required: []string{v}})
+		none.AnyOf = append(none.AnyOf, &Type{Required: []string{v}})
+	}
+	if !required {
+		c.OneOf = append(c.OneOf, &Type{Not: none})
+	}
+	return c
+}
+
+// choiceGroups returns the sorted variant properties of the choice elements of the definition with at least two variants,
+// whether the choices are required and whether they are declared by the element definitions.
+// The variants are recognized by the element definitions they are built from, else by the naming convention:
+// the element name followed by the type name. Choice elements do not repeat.
+func (g *Generator) choiceGroups(def *Type) (groups map[string][]string, required, declared map[string]bool) {
+	groups = make(map[string][]string)
+	required = make(map[string]bool)
+	declared = make(map[string]bool)
+	for name, prop := range def.Properties {
+		if strings.HasPrefix(name, extensionsPrefix) {
+			continue
+		}
+		var group string
+		if e := prop.Element; e != nil {
+			if !strings.HasSuffix(e.Path, choiceSuffix) {
+				continue
+			}
+			group = strings.TrimSuffix(e.Path[strings.LastIndex(e.Path, ".")+1:], choiceSuffix)
+			required[group] = required[group] || e.Min > 0
+			declared[group] = true
+		} else if group = g.choiceElementName(name, def.Properties); group == "" || prop.Type == "array" {
+			continue
+		}
+		groups[group] = append(groups[group], name)
+	}
+	for group, variants := range groups {
+		if len(variants) < 2 {
+			delete(groups, group)
+			delete(required, group)
+			delete(declared, group)
+			continue
+		}
+		sort.Strings(variants)
+	}
+	return groups, required, declared
+}
+
+// choiceElementName returns the choice element name of the property named by the element name and the type name,
+// e.g. value of valueCodeableConcept. It returns the empty string if the property is not the choice variant.
+func (g *Generator) choiceElementName(name string, props map[string]*Type) string {
+	for i, r := range name {
+		if i == 0 || !unicode.IsUpper(r) {
+			continue
+		}
+		if _, ok := props[name[:i]]; !ok && g.isDefinedType(name[i:]) {
+			return name[:i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const compartmentExt = "x-fhir-compartment"
+
+// AddCompartmentDefinition registers the compartment definition.
+// A definition with the same canonical URL already registered is replaced.
+func (g *Generator) AddCompartmentDefinition(cd *CompartmentDefinition) {
+	key := cd.URL
+	if key == "" {
+		key = cd.Code
+	}
+	g.CompartmentDefinitions[key] = cd
+}
+
+// compartmentDefinitions returns the compartment definitions sorted by the compartment codes.
+func (g *Generator) compartmentDefinitions() []*CompartmentDefinition {
+	cds := make([]*CompartmentDefinition, 0, len(g.CompartmentDefinitions))
+	for _, cd := range g.CompartmentDefinitions {
+		cds = append(cds, cd)
+	}
+	sort.Slice(cds, func(i, j int) bool { return cds[i].Code < cds[j].Code })
+	return cds
+}
+
+// createCompartmentPathes creates the search paths of the compartments: /{Compartment}/{id}/{type} of the member resources
+// and /{Compartment}/{id}/* of all of them. The paths are tagged with the compartment owner.
+func (g *Generator) createCompartmentPathes() {
+	for _, cd := range g.compartmentDefinitions() {
+		owner := cd.Code
+		if !cd.Search || !g.isCompartmentResource(owner) {
+			continue
+		}
+		var members []string
+		for _, res := range cd.Resource {
+			if len(res.Param) == 0 || !g.isCompartmentResource(res.Code) || !g.supports(res.Code, InteractionSearchType) {
+				continue
+			}
+			members = append(members, res.Code)
+			description := "This searches the resources " + res.Code + " of the compartment " + owner +
+				" linked by the search parameters: " + strings.Join(res.Param, ", ") + "."
+			g.Swagger.Paths["/"+owner+"/{id}/"+res.Code] = g.compartmentPathItem(cd, res.Code, description)
+		}
+		if len(members) > 0 {
+			description := "This searches all resources of the compartment " + owner + "."
+			g.Swagger.Paths["/"+owner+"/{id}/*"] = g.compartmentPathItem(cd, "", description)
+		}
+	}
+}
+
+// isCompartmentResource checks that the compartment or its member resource is declared and selected.
+func (g *Generator) isCompartmentResource(entity string) bool {
+	return g.isResource(entity) && g.isDeclared(entity) && g.isSelected(entity)
+}
+
+// compartmentPathItem returns the path item of the search of the entity in the compartment,
+// all resources are searched if the entity is empty.
+func (g *Generator) compartmentPathItem(cd *CompartmentDefinition, entity, description string) *openapi3.PathItem {
+	respBundle := &openapi3.ResponseRef{Ref: "#/components/responses/" + g.responseName("Bundle")}
+	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
+	compartment := cd.URL
+	if compartment == "" {
+		compartment = cd.Code
+	}
+	return &openapi3.PathItem{
+		Parameters: openapi3.Parameters{NewParameterWithSchema(InPath, "id", true, NewSchemaString())},
+		Get: &openapi3.Operation{
+			ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{compartmentExt: compartment}},
+			Parameters:     g.searchParameters(entity),
+			Description:    description,
+			Tags:           []string{cd.Code},
+			Responses: openapi3.Responses{
+				"200": respBundle,
+				"400": respErr,
+				"401": respErr,
+				"403": respErr,
+				"404": respErr,
+			},
+		},
+	}
+}
@@ -0,0 +1,235 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	componentSchemasPrefix = "#/components/schemas/"
+	// cycleBreakExt marks the property made nullable to break the cycle of the value references.
+	cycleBreakExt = "x-fhir-cycle-break"
+	// maxReportedCycleComponents limits the number of the components listed in the cycle warning.
+	maxReportedCycleComponents = 10
+)
+
+// reference is the reference of the component schema to another one.
+type reference struct {
+	target string
+	// value is set if the reference is embedded by value in the generated code,
+	// e.g. required properties and allOf items, so the cycle of such references can not be compiled.
+	value bool
+	// owner and property are the object and the name of the property, if the reference is the property schema.
+	owner    *openapi3.Schema
+	property string
+}
+
+// analyzeCycles finds the cycles of the component schema references and reports them if it is enabled.
+// If the breaking of the cycles is enabled, the required properties of the cycles of the value references
+// are made nullable, so the code generators use pointers for them. The allOf members are not properties
+// and can not be made nullable, so the cycles of the allOf compositions alone are not broken.
+// The value references of the remaining cycles are reported if the reporting or the breaking is enabled.
+func (g *Generator) analyzeCycles() {
+	schemas := g.Swagger.Components.Schemas
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	refs := make(map[string][]reference, len(schemas))
+	for _, name := range names {
+		refs[name] = schemaReferences(schemas[name].Value, nil)
+	}
+
+	g.Cycles = stronglyConnected(names, referenceTargets(refs, false), refs)
+	if g.ReportCycles {
+		for _, cycle := range g.Cycles {
+			listed := cycle
+			if len(listed) > maxReportedCycleComponents {
+				listed = listed[:maxReportedCycleComponents]
+			}
+			more := ""
+			if len(cycle) > len(listed) {
+				more = fmt.Sprintf(" and %d more", len(cycle)-len(listed))
+			}
+			g.warnf("reference cycle through %d components: %s%s", len(cycle), strings.Join(listed, ", "), more)
+		}
+	}
+
+	if g.BreakCycles {
+		forEachCycleReference(stronglyConnected(names, referenceTargets(refs, true), refs), refs, func(_ string, ref *reference) {
+			if ref.owner != nil {
+				breakCycle(ref.owner, ref.property)
+				ref.value = false
+			}
+		})
+	}
+	if !g.ReportCycles && !g.BreakCycles {
+		return
+	}
+	forEachCycleReference(stronglyConnected(names, referenceTargets(refs, true), refs), refs, func(name string, ref *reference) {
+		g.warnf("%s refers to %s by value in the reference cycle", name, ref.target)
+	})
+}
+
+// referenceTargets returns the targets of the references of the component, the references by value only if it is set.
+func referenceTargets(refs map[string][]reference, value bool) func(string) []string {
+	return func(name string) []string {
+		var targets []string
+		for _, ref := range refs[name] {
+			if ref.value || !value {
+				targets = append(targets, ref.target)
+			}
+		}
+		return targets
+	}
+}
+
+// forEachCycleReference calls fn for the value references between the components of the same cycle by the referring components.
+func forEachCycleReference(cycles [][]string, refs map[string][]reference, fn func(name string, ref *reference)) {
+	for _, cycle := range cycles {
+		members := make(map[string]bool, len(cycle))
+		for _, name := range cycle {
+			members[name] = true
+		}
+		for _, name := range cycle {
+			for i := range refs[name] {
+				if ref := &refs[name][i]; ref.value && members[ref.target] {
+					fn(name, ref)
+				}
+			}
+		}
+	}
+}
+
+// breakCycle makes the property nullable, the reference is wrapped since the siblings of the reference are ignored.
+func breakCycle(owner *openapi3.Schema, property string) {
+	prop := owner.Properties[property]
+	schema := prop.Value
+	if prop.Ref != "" {
+		schema = &openapi3.Schema{AllOf: openapi3.SchemaRefs{openapi3.NewSchemaRef(prop.Ref, nil)}}
+		owner.Properties[property] = openapi3.NewSchemaRef("", schema)
+	}
+	schema.Nullable = true
+	if schema.Extensions == nil {
+		schema.Extensions = make(map[string]interface{})
+	}
+	schema.Extensions[cycleBreakExt] = true
+}
+
+// schemaReferences returns the references of the inline schema to the component schemas.
+// The references of the required properties are references by value. The owner is the object
+// the schema is a property of.
+func schemaReferences(schema *openapi3.Schema, refs []reference) []reference {
+	if schema == nil {
+		return refs
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	add := func(ref *openapi3.SchemaRef, value bool, owner *openapi3.Schema, property string) {
+		if ref == nil {
+			return
+		}
+		if ref.Ref != "" {
+			if strings.HasPrefix(ref.Ref, componentSchemasPrefix) {
+				refs = append(refs, reference{
+					target:   strings.TrimPrefix(ref.Ref, componentSchemasPrefix),
+					value:    value,
+					owner:    owner,
+					property: property,
+				})
+			}
+			return
+		}
+		refs = schemaReferences(ref.Value, refs)
+	}
+
+	for name, prop := range schema.Properties {
+		add(prop, required[name] && !(prop.Value != nil && prop.Value.Nullable), schema, name)
+	}
+	for _, ref := range schema.AllOf {
+		add(ref, true, nil, "")
+	}
+	for _, list := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf} {
+		for _, ref := range list {
+			add(ref, false, nil, "")
+		}
+	}
+	add(schema.Items, false, nil, "")
+	add(schema.AdditionalProperties, false, nil, "")
+	add(schema.Not, false, nil, "")
+	return refs
+}
+
+// stronglyConnected returns the sorted strongly connected components of the graph that are cycles,
+// that is have more than one node or a self-reference.
+func stronglyConnected(nodes []string, edges func(string) []string, refs map[string][]reference) [][]string {
+	index := make(map[string]int, len(nodes))
+	low := make(map[string]int, len(nodes))
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(node string)
+	connect = func(node string) {
+		index[node] = len(index)
+		low[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range edges(node) {
+			if _, ok := refs[next]; !ok {
+				continue
+			}
+			if _, visited := index[next]; !visited {
+				connect(next)
+				if low[next] < low[node] {
+					low[node] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[node] {
+				low[node] = index[next]
+			}
+		}
+
+		if low[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		if len(component) > 1 || hasEdge(edges(node), node) {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := index[node]; !visited {
+			connect(node)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+func hasEdge(edges []string, node string) bool {
+	for _, e := range edges {
+		if e == node {
+			return true
+		}
+	}
+	return false
+}
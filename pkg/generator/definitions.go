@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const resourceTypeProperty = "resourceType"
+
+// isResource checks that the definition of the entity is a resource definition.
+func (g *Generator) isResource(entity string) bool {
+	schema, ok := g.Schema.Definitions[entity]
+	if !ok {
+		return false
+	}
+	_, ok = schema.Properties[resourceTypeProperty]
+	return ok
+}
+
+// isDomainResource checks that the entity is a resource containing narrative, extensions, and contained resources.
+func (g *Generator) isDomainResource(entity string) bool {
+	if !g.isResource(entity) {
+		return false
+	}
+	_, ok := g.Schema.Definitions[entity].Properties["text"]
+	return ok
+}
+
+// isGenerated checks that the definition is generated from the profile, the reference targets or the Bundle,
+// not from the base definitions.
+func (g *Generator) isGenerated(name string) bool {
+	_, profile := g.profiles[name]
+	_, reference := g.referenceTypes[name]
+	return profile || reference || isSystemBundle(name)
+}
+
+// resourceNames returns the sorted names of the resource definitions.
+func (g *Generator) resourceNames() []string {
+	var names []string
+	for name := range g.Schema.Definitions {
+		if len(name) == 0 || !unicode.IsUpper([]rune(name)[0]) || g.isGenerated(name) {
+			continue
+		}
+		if g.isResource(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addResourceDiscriminator adds the discriminator by the resource type to the resource list,
+// so the resources of Bundle entries, Parameters and contained resources referring to it can be dispatched.
+// The mapping is built from the resources of the list, the property name is taken from the root discriminator of the schema.
+func (g *Generator) addResourceDiscriminator() {
+	list, ok := g.Schema.Definitions[resourceListName]
+	if !ok || len(list.OneOf) == 0 {
+		return
+	}
+	propertyName := resourceTypeProperty
+	if g.Schema.Discriminator != nil && g.Schema.Discriminator.PropertyName != "" {
+		propertyName = g.Schema.Discriminator.PropertyName
+	}
+	mapping := make(map[string]string, len(list.OneOf))
+	for _, t := range list.OneOf {
+		if strings.HasPrefix(t.Ref, definitionsPrefix) {
+			mapping[strings.TrimPrefix(t.Ref, definitionsPrefix)] = t.Ref
+		}
+	}
+	list.Discriminator = &Discriminator{PropertyName: propertyName, Mapping: mapping}
+}
@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	exampleDir = "example"
+	// representativeExample is the id of the main example of the resource type in the FHIR specification.
+	representativeExample = "example"
+)
+
+// Example is the example resource.
+type Example struct {
+	// Name is the id of the example or the name of its file.
+	Name  string
+	Value json.RawMessage
+}
+
+// AddExample registers the example of the resource type. The name is made unique by the number if it is taken.
+func (g *Generator) AddExample(resourceType, name string, value json.RawMessage) {
+	if name == "" {
+		name = representativeExample
+	}
+	unique := name
+	for i := 2; g.hasExample(resourceType, unique); i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	g.Examples[resourceType] = append(g.Examples[resourceType], &Example{Name: unique, Value: value})
+}
+
+func (g *Generator) hasExample(resourceType, name string) bool {
+	for _, e := range g.Examples[resourceType] {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadExamples loads the example resources of the directory, e.g. examples-json of the FHIR specification,
+// or of the "example" folder of the FHIR NPM package: a .tgz file, a package directory or id#version from the package cache.
+func (g *Generator) LoadExamples(src string) error {
+	if strings.HasSuffix(src, ".tgz") || strings.HasSuffix(src, ".tar.gz") {
+		return walkPackageArchive(src, path.Join(packageDir, exampleDir), func(file string, r io.Reader) error {
+			return g.loadExample(file, r)
+		})
+	}
+	dir := src
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		if dir, err = g.cachedPackageDir(src); err != nil {
+			return err
+		}
+	}
+	for _, sub := range []string{filepath.Join(packageDir, exampleDir), exampleDir} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err == nil && info.IsDir() {
+			dir = filepath.Join(dir, sub)
+			break
+		}
+	}
+	return walkResourceDir(dir, func(name string) error {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := g.loadExample(name, f); err != nil {
+			return fmt.Errorf("loading «%s»: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// loadExample registers the example resource of the file, the files of other content are skipped.
+func (g *Generator) loadExample(file string, r io.Reader) error {
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("decoding example: %w", err)
+	}
+	var header struct {
+		ResourceType string `json:"resourceType"`
+		ID           string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.ResourceType == "" {
+		return nil
+	}
+	name := header.ID
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(filepath.ToSlash(file)), path.Ext(file))
+	}
+	g.AddExample(header.ResourceType, name, data)
+	return nil
+}
+
+// resourceExamples returns the examples of the resource type, the representative example goes first.
+// The number of the examples is limited by MaxExamples.
+func (g *Generator) resourceExamples(resourceType string) []*Example {
+	examples := append([]*Example(nil), g.Examples[resourceType]...)
+	sort.Slice(examples, func(i, j int) bool {
+		if (examples[i].Name == representativeExample) != (examples[j].Name == representativeExample) {
+			return examples[i].Name == representativeExample
+		}
+		return examples[i].Name < examples[j].Name
+	})
+	if g.MaxExamples > 0 && len(examples) > g.MaxExamples {
+		examples = examples[:g.MaxExamples]
+	}
+	return examples
+}
+
+// attachExamples sets the representative examples of the resource components and the named examples
+// of the request and response bodies of the resources.
+func (g *Generator) attachExamples() {
+	named := make(map[string]openapi3.Examples, len(g.Examples))
+	for resourceType := range g.Examples {
+		name := g.componentName(resourceType)
+		schema, ok := g.Swagger.Components.Schemas[name]
+		if !ok || schema.Value == nil {
+			continue
+		}
+		examples := g.resourceExamples(resourceType)
+		if schema.Value.Example == nil {
+			schema.Value.Example = examples[0].Value
+		}
+		refs := make(openapi3.Examples, len(examples))
+		for _, e := range examples {
+			refs[e.Name] = &openapi3.ExampleRef{Value: openapi3.NewExample(e.Value)}
+		}
+		named[componentSchemasPrefix+name] = refs
+	}
+	if len(named) == 0 {
+		return
+	}
+
+	attach := func(content openapi3.Content) {
+		for _, media := range content {
+			if media.Schema == nil || media.Examples != nil {
+				continue
+			}
+			if examples, ok := named[media.Schema.Ref]; ok {
+				media.Examples = examples
+			}
+		}
+	}
+	for _, resp := range g.Swagger.Components.Responses {
+		if resp.Value != nil {
+			attach(resp.Value.Content)
+		}
+	}
+	for _, item := range g.Swagger.Paths {
+		for _, op := range item.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				attach(op.RequestBody.Value.Content)
+			}
+			for _, resp := range op.Responses {
+				if resp.Value != nil {
+					attach(resp.Value.Content)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package generator
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Bundle is a container for a collection of FHIR resources.
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// BundleEntry is an entry in a Bundle.
+type BundleEntry struct {
+	FullURL  string          `json:"fullUrl,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// SearchParameter is a search parameter that defines a named search item that can be used to search/filter on a resource.
+type SearchParameter struct {
+	ResourceType string   `json:"resourceType"`
+	ID           string   `json:"id,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Code         string   `json:"code"`
+	Base         []string `json:"base"`
+	Type         string   `json:"type"`
+	Expression   string   `json:"expression,omitempty"`
+	Target       []string `json:"target,omitempty"`
+	Modifier     []string `json:"modifier,omitempty"`
+	Comparator   []string `json:"comparator,omitempty"`
+}
+
+// Structure definition kinds.
+const (
+	KindPrimitiveType = "primitive-type"
+	KindComplexType   = "complex-type"
+	KindResource      = "resource"
+	KindLogical       = "logical"
+)
+
+// Structure definition derivations.
+const (
+	DerivationSpecialization = "specialization"
+	DerivationConstraint     = "constraint"
+)
+
+// StructureDefinition is a definition of a FHIR structure.
+type StructureDefinition struct {
+	ResourceType   string       `json:"resourceType"`
+	ID             string       `json:"id,omitempty"`
+	URL            string       `json:"url,omitempty"`
+	Name           string       `json:"name,omitempty"`
+	Title          string       `json:"title,omitempty"`
+	Description    string       `json:"description,omitempty"`
+	Extension      []Extension  `json:"extension,omitempty"`
+	FHIRVersion    string       `json:"fhirVersion,omitempty"`
+	Kind           string       `json:"kind"`
+	Abstract       bool         `json:"abstract"`
+	Type           string       `json:"type"`
+	BaseDefinition string       `json:"baseDefinition,omitempty"`
+	Derivation     string       `json:"derivation,omitempty"`
+	Snapshot       *ElementList `json:"snapshot,omitempty"`
+	Differential   *ElementList `json:"differential,omitempty"`
+}
+
+// ElementList is a list of element definitions of a structure definition snapshot or differential.
+type ElementList struct {
+	Element []*ElementDefinition `json:"element"`
+}
+
+// ElementDefinition captures constraints on each element within the resource, profile, or extension.
+type ElementDefinition struct {
+	ID               string                        `json:"id,omitempty"`
+	Path             string                        `json:"path"`
+	SliceName        string                        `json:"sliceName,omitempty"`
+	Short            string                        `json:"short,omitempty"`
+	Definition       string                        `json:"definition,omitempty"`
+	Min              int                           `json:"min"`
+	Max              string                        `json:"max,omitempty"`
+	Base             *ElementDefinitionBase        `json:"base,omitempty"`
+	ContentReference string                        `json:"contentReference,omitempty"`
+	Type             []ElementDefinitionType       `json:"type,omitempty"`
+	MaxLength        *uint64                       `json:"maxLength,omitempty"`
+	Constraint       []ElementDefinitionConstraint `json:"constraint,omitempty"`
+	MustSupport      bool                          `json:"mustSupport,omitempty"`
+	IsModifier       bool                          `json:"isModifier,omitempty"`
+	IsSummary        bool                          `json:"isSummary,omitempty"`
+	Binding          *ElementDefinitionBinding     `json:"binding,omitempty"`
+	// Fixed is the value of the fixed[x] element.
+	Fixed interface{} `json:"-"`
+	// Pattern is the value of the pattern[x] element.
+	Pattern interface{} `json:"-"`
+}
+
+// UnmarshalJSON decodes the element definition including the fixed[x] and pattern[x] choice elements.
+func (e *ElementDefinition) UnmarshalJSON(data []byte) error {
+	type plain ElementDefinition
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, value := range fields {
+		var err error
+		switch {
+		case isChoiceOf(key, "fixed"):
+			err = json.Unmarshal(value, &e.Fixed)
+		case isChoiceOf(key, "pattern"):
+			err = json.Unmarshal(value, &e.Pattern)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isChoiceOf checks that the JSON property name is a type variant of the choice element, e.g. fixedString of fixed[x].
+func isChoiceOf(name, element string) bool {
+	if !strings.HasPrefix(name, element) || len(name) == len(element) {
+		return false
+	}
+	return unicode.IsUpper([]rune(name[len(element):])[0])
+}
+
+// ElementDefinitionBase is the base definition information for tools.
+type ElementDefinitionBase struct {
+	Path string `json:"path"`
+	Min  int    `json:"min"`
+	Max  string `json:"max"`
+}
+
+// ElementDefinitionType is a data type and profile for an element.
+type ElementDefinitionType struct {
+	Extension     []Extension `json:"extension,omitempty"`
+	Code          string      `json:"code"`
+	Profile       Canonicals  `json:"profile,omitempty"`
+	TargetProfile Canonicals  `json:"targetProfile,omitempty"`
+}
+
+// ElementDefinitionConstraint is a condition that must evaluate to true.
+type ElementDefinitionConstraint struct {
+	Key        string `json:"key"`
+	Severity   string `json:"severity"`
+	Human      string `json:"human"`
+	Expression string `json:"expression,omitempty"`
+}
+
+// ElementDefinitionBinding is a value set binding of a coded element.
+type ElementDefinitionBinding struct {
+	Strength    string `json:"strength"`
+	Description string `json:"description,omitempty"`
+	ValueSet    string `json:"valueSet,omitempty"`
+}
+
+// UnmarshalJSON decodes the binding including the STU3 value set elements valueSetUri and valueSetReference.
+func (b *ElementDefinitionBinding) UnmarshalJSON(data []byte) error {
+	type plain ElementDefinitionBinding
+	var stu3 struct {
+		plain
+		ValueSetURI       string    `json:"valueSetUri"`
+		ValueSetReference Canonical `json:"valueSetReference"`
+	}
+	if err := json.Unmarshal(data, &stu3); err != nil {
+		return err
+	}
+	*b = ElementDefinitionBinding(stu3.plain)
+	if b.ValueSet == "" {
+		b.ValueSet = stu3.ValueSetURI
+	}
+	if b.ValueSet == "" {
+		b.ValueSet = string(stu3.ValueSetReference)
+	}
+	return nil
+}
+
+// Extension is an additional content defined by implementations.
+// Only the value types used by conformance resources are decoded.
+type Extension struct {
+	URL          string `json:"url"`
+	ValueString  string `json:"valueString,omitempty"`
+	ValueURL     string `json:"valueUrl,omitempty"`
+	ValueURI     string `json:"valueUri,omitempty"`
+	ValueCode    string `json:"valueCode,omitempty"`
+	ValueBoolean *bool  `json:"valueBoolean,omitempty"`
+}
+
+// Canonicals are the canonical URLs. STU3 uses a single URL where R4 uses the list, e.g. targetProfile, so both are decoded.
+type Canonicals []string
+
+// UnmarshalJSON decodes the list of the canonical URLs or the single canonical URL.
+func (c *Canonicals) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*c = Canonicals{url}
+		return nil
+	}
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err != nil {
+		return err
+	}
+	*c = urls
+	return nil
+}
+
+// Canonical is the canonical URL. STU3 uses a Reference where R4 uses the canonical URL,
+// e.g. the profile of the CapabilityStatement resource, so both are decoded.
+type Canonical string
+
+// UnmarshalJSON decodes the canonical URL or the reference to it.
+func (c *Canonical) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*c = Canonical(url)
+		return nil
+	}
+	var ref struct {
+		Reference string `json:"reference"`
+	}
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return err
+	}
+	*c = Canonical(ref.Reference)
+	return nil
+}
+
+// Operation parameter uses.
+const (
+	OperationParameterIn  = "in"
+	OperationParameterOut = "out"
+)
+
+// OperationDefinition is a formal computable definition of an operation (on the RESTful interface) or a named query.
+type OperationDefinition struct {
+	ResourceType string                         `json:"resourceType"`
+	ID           string                         `json:"id,omitempty"`
+	URL          string                         `json:"url,omitempty"`
+	Name         string                         `json:"name,omitempty"`
+	Title        string                         `json:"title,omitempty"`
+	Kind         string                         `json:"kind,omitempty"`
+	Description  string                         `json:"description,omitempty"`
+	AffectsState bool                           `json:"affectsState,omitempty"`
+	Code         string                         `json:"code"`
+	Resource     []string                       `json:"resource,omitempty"`
+	System       bool                           `json:"system"`
+	Type         bool                           `json:"type"`
+	Instance     bool                           `json:"instance"`
+	Parameter    []OperationDefinitionParameter `json:"parameter,omitempty"`
+}
+
+// OperationDefinitionParameter is a parameter for the operation or query.
+type OperationDefinitionParameter struct {
+	Name          string                         `json:"name"`
+	Use           string                         `json:"use"`
+	Min           int                            `json:"min"`
+	Max           string                         `json:"max"`
+	Documentation string                         `json:"documentation,omitempty"`
+	Type          string                         `json:"type,omitempty"`
+	TargetProfile Canonicals                     `json:"targetProfile,omitempty"`
+	SearchType    string                         `json:"searchType,omitempty"`
+	Part          []OperationDefinitionParameter `json:"part,omitempty"`
+}
+
+// CapabilityStatement is a statement of the capabilities of a FHIR server.
+type CapabilityStatement struct {
+	ResourceType string                    `json:"resourceType"`
+	ID           string                    `json:"id,omitempty"`
+	URL          string                    `json:"url,omitempty"`
+	Name         string                    `json:"name,omitempty"`
+	FHIRVersion  string                    `json:"fhirVersion,omitempty"`
+	Rest         []CapabilityStatementRest `json:"rest,omitempty"`
+}
+
+// CapabilityStatementRest is a definition of a RESTful capabilities of the solution.
+type CapabilityStatementRest struct {
+	Mode        string                               `json:"mode"`
+	Resource    []CapabilityStatementResource        `json:"resource,omitempty"`
+	Interaction []CapabilityStatementInteraction     `json:"interaction,omitempty"`
+	SearchParam []CapabilityStatementSearchParameter `json:"searchParam,omitempty"`
+	Operation   []CapabilityStatementOperation       `json:"operation,omitempty"`
+}
+
+// CapabilityStatementResource is a resource served on the REST interface.
+type CapabilityStatementResource struct {
+	Type              string                               `json:"type"`
+	Profile           Canonical                            `json:"profile,omitempty"`
+	SupportedProfile  []string                             `json:"supportedProfile,omitempty"`
+	Interaction       []CapabilityStatementInteraction     `json:"interaction,omitempty"`
+	Versioning        string                               `json:"versioning,omitempty"`
+	ReadHistory       bool                                 `json:"readHistory,omitempty"`
+	UpdateCreate      bool                                 `json:"updateCreate,omitempty"`
+	ConditionalCreate bool                                 `json:"conditionalCreate,omitempty"`
+	ConditionalRead   string                               `json:"conditionalRead,omitempty"`
+	ConditionalUpdate bool                                 `json:"conditionalUpdate,omitempty"`
+	ConditionalDelete string                               `json:"conditionalDelete,omitempty"`
+	SearchParam       []CapabilityStatementSearchParameter `json:"searchParam,omitempty"`
+	Operation         []CapabilityStatementOperation       `json:"operation,omitempty"`
+}
+
+// CapabilityStatementInteraction is an operation supported by the server.
+type CapabilityStatementInteraction struct {
+	Code string `json:"code"`
+}
+
+// CapabilityStatementSearchParameter is a search parameter supported by the server.
+type CapabilityStatementSearchParameter struct {
+	Name          string    `json:"name"`
+	Definition    Canonical `json:"definition,omitempty"`
+	Type          string    `json:"type"`
+	Documentation string    `json:"documentation,omitempty"`
+}
+
+// CapabilityStatementOperation is an operation supported by the server.
+type CapabilityStatementOperation struct {
+	Name          string    `json:"name"`
+	Definition    Canonical `json:"definition"`
+	Documentation string    `json:"documentation,omitempty"`
+}
+
+// Binding strengths.
+const (
+	BindingRequired   = "required"
+	BindingExtensible = "extensible"
+	BindingPreferred  = "preferred"
+	BindingExample    = "example"
+)
+
+// ValueSet is a set of codes drawn from code systems.
+type ValueSet struct {
+	ResourceType string             `json:"resourceType"`
+	ID           string             `json:"id,omitempty"`
+	URL          string             `json:"url,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	Compose      *ValueSetCompose   `json:"compose,omitempty"`
+	Expansion    *ValueSetExpansion `json:"expansion,omitempty"`
+}
+
+// ValueSetCompose is a content logical definition of the value set.
+type ValueSetCompose struct {
+	Include []ValueSetInclude `json:"include,omitempty"`
+	Exclude []ValueSetInclude `json:"exclude,omitempty"`
+}
+
+// ValueSetInclude is a set of codes included from the code system or the value sets.
+type ValueSetInclude struct {
+	System   string            `json:"system,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Concept  []ValueSetConcept `json:"concept,omitempty"`
+	Filter   []json.RawMessage `json:"filter,omitempty"`
+	ValueSet []string          `json:"valueSet,omitempty"`
+}
+
+// ValueSetConcept is a concept defined in the code system.
+type ValueSetConcept struct {
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+// ValueSetExpansion is a precalculated list of the codes of the value set.
+type ValueSetExpansion struct {
+	Contains []ValueSetContains `json:"contains,omitempty"`
+}
+
+// ValueSetContains is a code in the value set expansion.
+type ValueSetContains struct {
+	System   string             `json:"system,omitempty"`
+	Abstract bool               `json:"abstract,omitempty"`
+	Code     string             `json:"code,omitempty"`
+	Display  string             `json:"display,omitempty"`
+	Contains []ValueSetContains `json:"contains,omitempty"`
+}
+
+// Code system content modes.
+const (
+	CodeSystemContentComplete = "complete"
+)
+
+// CodeSystem declares the existence of the code system and its concepts.
+type CodeSystem struct {
+	ResourceType string              `json:"resourceType"`
+	ID           string              `json:"id,omitempty"`
+	URL          string              `json:"url,omitempty"`
+	Name         string              `json:"name,omitempty"`
+	ValueSet     string              `json:"valueSet,omitempty"`
+	Content      string              `json:"content,omitempty"`
+	Concept      []CodeSystemConcept `json:"concept,omitempty"`
+}
+
+// CodeSystemConcept is a concept of the code system, nested concepts are its specializations.
+type CodeSystemConcept struct {
+	Code    string              `json:"code"`
+	Display string              `json:"display,omitempty"`
+	Concept []CodeSystemConcept `json:"concept,omitempty"`
+}
+
+// CompartmentDefinition is a definition of the compartment: a logical grouping of resources which share
+// a common property, e.g. the resources of the patient.
+type CompartmentDefinition struct {
+	ResourceType string                          `json:"resourceType"`
+	ID           string                          `json:"id,omitempty"`
+	URL          string                          `json:"url,omitempty"`
+	Name         string                          `json:"name,omitempty"`
+	Code         string                          `json:"code"`
+	Search       bool                            `json:"search"`
+	Resource     []CompartmentDefinitionResource `json:"resource,omitempty"`
+}
+
+// CompartmentDefinitionResource is a resource type of the compartment and the search parameters linking it to the compartment.
+// The resource is not a member of the compartment if it has no parameters.
+type CompartmentDefinitionResource struct {
+	Code          string   `json:"code"`
+	Param         []string `json:"param,omitempty"`
+	Documentation string   `json:"documentation,omitempty"`
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -24,9 +23,92 @@ const (
 )
 
 type Generator struct {
+	// SkipUnderscore drops the primitive extension properties if PrimitiveExtensions is not set.
 	SkipUnderscore bool
-	Swagger        *openapi3.Swagger
-	Schema         *Schema
+	// PrimitiveExtensions is the strategy of the primitive extension properties, e.g. _birthDate.
+	PrimitiveExtensions PrimitiveExtensionStrategy
+	Swagger             *openapi3.Swagger
+	Schema              *Schema
+	// SearchParameters are the search parameters by base resources.
+	SearchParameters map[string][]*SearchParameter
+	// StructureDefinitions are the structure definitions by canonical URLs.
+	StructureDefinitions map[string]*StructureDefinition
+	// OperationDefinitions are the operation definitions by canonical URLs.
+	OperationDefinitions map[string]*OperationDefinition
+	// CompartmentDefinitions are the compartment definitions by canonical URLs.
+	CompartmentDefinitions map[string]*CompartmentDefinition
+	// ValueSets are the value sets by canonical URLs.
+	ValueSets map[string]*ValueSet
+	// CodeSystems are the code systems by canonical URLs.
+	CodeSystems map[string]*CodeSystem
+	// Version is the FHIR version, it is detected by the input if it is not set.
+	Version FHIRVersion
+	// ElementMetadata annotates the definitions built from the structure definitions with the element metadata.
+	ElementMetadata bool
+	// ReferenceTypes enables the reference components narrowed to the target resource types, e.g. Reference_Patient.
+	ReferenceTypes bool
+	// PrimitiveMapping is the mode of the mapping of the primitive types, the lossy mapping is used if it is not set.
+	PrimitiveMapping PrimitiveMapping
+	// TypeMapping overrides the mapping of the primitive types by the type names.
+	TypeMapping map[string]*Type
+	// DecimalAsString maps decimals to the patterned strings to keep their precision.
+	DecimalAsString bool
+	// ChoiceConstraints constrains the choice elements to have at most one variant.
+	ChoiceConstraints bool
+	// Include are the names or glob patterns of the resources the paths are generated for.
+	Include []string
+	// Exclude are the names or glob patterns of the resources the paths are not generated for.
+	Exclude []string
+	// Categories are the categories of the resources the paths are generated for, e.g. Clinical or Clinical.Diagnostics.
+	Categories []string
+	// Examples are the example resources by resource types.
+	Examples map[string][]*Example
+	// MaxExamples limits the number of the named examples of the bodies, there is no limit if it is zero.
+	MaxExamples int
+	// Naming is the strategy of the naming of the components, the definition names are kept if it is not set.
+	Naming NamingStrategy
+	// NamePrefix and NameSuffix are added to the component names.
+	NamePrefix, NameSuffix string
+	// NameMapping overrides the component names of the definitions.
+	NameMapping map[string]string
+	// ReportCycles warns about the cycles of the component references.
+	ReportCycles bool
+	// BreakCycles makes the required properties of the cycles of the value references nullable.
+	// The allOf members can not be made nullable, the cycles of the allOf compositions alone are reported instead.
+	BreakCycles bool
+	// MergePatch adds JSON Merge Patch to the request bodies of the patch interactions.
+	MergePatch bool
+	// SMART adds the SMART App Launch configuration endpoint /.well-known/smart-configuration, it is set by New.
+	SMART bool
+	// UDAP adds the UDAP server metadata endpoint /.well-known/udap.
+	UDAP bool
+	// Strict generates exactly the FHIR RESTful API: the paths of the resources only, vread, history, search using POST,
+	// the conditional interactions and their headers and the status codes of the interactions.
+	// The capability statement restricts the interactions if it is set.
+	Strict bool
+	// Capability is the capability statement the generated API is restricted to.
+	Capability *CapabilityStatement
+	// PackageCache is the FHIR package cache directory used to locate packages by references.
+	PackageCache string
+	// Profiles are the canonical URLs or names of the profiles to generate the components for, "*" selects all loaded profiles.
+	Profiles []string
+	// ProfileBodies enables using the profiles as the request and response bodies of the constrained resources.
+	ProfileBodies bool
+	// Verbose adds the detailed warnings, e.g. the definitions that are left open since their primitive extension
+	// properties are not generated.
+	Verbose bool
+
+	// Cycles are the sorted names of the component schemas of the reference cycles found by the generation.
+	Cycles [][]string
+	// Warnings are the messages about the input that can not be represented in the generated specification.
+	Warnings []string
+
+	// profiles are the profiles by component names.
+	profiles map[string]*StructureDefinition
+	// mapper is the mapper of the primitive types.
+	mapper *TypeMapper
+	// referenceTypes are the target resource types of the reference components by component names.
+	referenceTypes map[string][]string
 }
 
 const baseOpenAPIData = `
@@ -77,21 +159,83 @@ func New() *Generator {
 	if err != nil {
 		panic(fmt.Errorf("loading base openapi data: %w", err))
 	}
-	return &Generator{Swagger: s, Schema: &Schema{}, SkipUnderscore: true}
+	return &Generator{
+		Swagger:                s,
+		Schema:                 &Schema{},
+		SkipUnderscore:         true,
+		SMART:                  true,
+		SearchParameters:       make(map[string][]*SearchParameter),
+		StructureDefinitions:   make(map[string]*StructureDefinition),
+		OperationDefinitions:   make(map[string]*OperationDefinition),
+		CompartmentDefinitions: make(map[string]*CompartmentDefinition),
+		TypeMapping:            make(map[string]*Type),
+		NameMapping:            make(map[string]string),
+		Examples:               make(map[string][]*Example),
+		ValueSets:              make(map[string]*ValueSet),
+		CodeSystems:            make(map[string]*CodeSystem),
+		PackageCache:           DefaultPackageCache(),
+		profiles:               make(map[string]*StructureDefinition),
+		referenceTypes:         make(map[string][]string),
+	}
 }
 
 func (g *Generator) String() string {
 	return fmt.Sprintf("%+v", g.Swagger)
 }
 
+// Do generates the OpenAPI specification and writes it to the output.
+// The schema is the FHIR JSON schema, it can be nil if the structure definitions are loaded.
+// The definitions built from the structure definitions replace the definitions of the JSON schema.
 func (g *Generator) Do(schema io.Reader, output io.Writer, format Format) error {
+	if schema != nil {
+		if err := g.encodeSchema(schema); err != nil {
+			return err
+		}
+	}
+	if g.Schema.Definitions == nil {
+		g.Schema.Definitions = make(Definitions)
+	}
+	if g.Version == "" {
+		g.Version = g.detectVersion()
+	}
+	g.addCapabilitySearchParameters()
 	g.initSwagger()
-
-	if err := g.encodeSchema(schema); err != nil {
+	if len(g.StructureDefinitions) > 0 {
+		defs, err := g.convertStructureDefinitions()
+		if err != nil {
+			return err
+		}
+		for name, def := range defs {
+			g.Schema.Definitions[name] = def
+		}
+	}
+	if err := g.addProfileDefinitions(); err != nil {
+		return err
+	}
+	// The faithful mapping is built from the final definitions of the primitive types.
+	g.initTypeMapper()
+	g.selectResources()
+	g.addResourceDiscriminator()
+	g.annotateChoices()
+	g.reportUnsupportedKeywords()
+	g.addBundleDefinitions()
+	g.createSystemPathes()
+	if err := g.checkComponentNames(); err != nil {
 		return err
 	}
 
 	g.Swagger.Components.Schemas = g.convertNamedSchemas(g.Schema.Definitions, true)
+	g.createOperationPathes()
+	g.createCompartmentPathes()
+	if err := g.addPatchSchemas(); err != nil {
+		return err
+	}
+	if err := g.addWellKnownPathes(); err != nil {
+		return err
+	}
+	g.prune()
+	g.attachExamples()
+	g.analyzeCycles()
 
 	b, err := json.MarshalIndent(g.Swagger, "", "    ")
 	if err != nil {
@@ -112,17 +256,20 @@ func (g *Generator) initSwagger() {
 	g.Swagger.Components.Responses = openapi3.Responses{
 		"Error": &openapi3.ResponseRef{Value: &openapi3.Response{
 			Description: ptr.String("Error"),
-			Content:     openapi3.NewContentWithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/OperationOutcome", nil)),
+			Content:     openapi3.NewContentWithJSONSchemaRef(g.schemaRef("OperationOutcome")),
 		}},
-		"Bundle" + ResposePostfix: &openapi3.ResponseRef{Value: &openapi3.Response{
+		g.responseName("Bundle"): &openapi3.ResponseRef{Value: &openapi3.Response{
 			Description: ptr.String("OK"),
-			Content:     openapi3.NewContentWithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/Bundle", nil)),
+			Content:     openapi3.NewContentWithJSONSchemaRef(g.schemaRef("Bundle")),
 		}},
 	}
 
 	// Parameters
-	g.Swagger.Components.Parameters = openapi3.ParametersMap{
-		"search": &openapi3.ParameterRef{Value: &openapi3.Parameter{
+	g.Swagger.Components.Parameters = openapi3.ParametersMap{}
+	if len(g.SearchParameters) > 0 {
+		g.initSearchParameters()
+	} else {
+		g.Swagger.Components.Parameters["search"] = &openapi3.ParameterRef{Value: &openapi3.Parameter{
 			Name:     "search",
 			In:       "query",
 			Required: true,
@@ -155,89 +302,24 @@ func (g *Generator) initSwagger() {
 					AdditionalPropertiesAllowed: ptr.Bool(true),
 				},
 			},
-		}},
-		// "_id": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_id",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_lastUpdated": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_lastUpdated",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_tag": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_tag",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_profile": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_profile",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_security": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_security",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_text": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_text",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_content": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_content",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_list": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_list",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_has": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_has",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-		// "_type": &openapi3.ParameterRef{Value: &openapi3.Parameter{
-		// 	Name:     "_type",
-		// 	In:       "query",
-		// 	Required: false,
-		// 	Schema:   &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
-		// }},
-	}
-
-	// Path /
-	contentBundle := openapi3.NewContentWithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/Bundle", nil))
-	respBundle := &openapi3.ResponseRef{Ref: "#/components/responses/Bundle" + ResposePostfix}
-	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
-	responsesBundle := openapi3.Responses{
-		"200": respBundle,
-		"201": respBundle,
-		"400": respErr,
-		"403": respErr,
-		"404": respErr,
-		"405": respErr,
-		"409": respErr,
-		"422": respErr,
+		}}
+		for code, v := range versionResultParameters {
+			if !g.since(v) {
+				delete(g.Swagger.Components.Parameters["search"].Value.Schema.Value.Properties, code)
+			}
+		}
 	}
+}
 
-	g.Swagger.Paths["/"] = &openapi3.PathItem{
-		Get: &openapi3.Operation{
-			Parameters: openapi3.Parameters{
-				NewParameterRef("search"),
-			},
+// createSystemPathes creates the paths of the system interactions: search, batch and transaction, history.
+func (g *Generator) createSystemPathes() {
+	respBundle := &openapi3.ResponseRef{Ref: "#/components/responses/" + g.responseName("Bundle")}
+	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
+
+	root := &openapi3.PathItem{}
+	if g.supports("", InteractionSearchSystem) {
+		root.Get = &openapi3.Operation{
+			Parameters:  g.searchParameters(""),
 			Description: "This searches all resources of a particular type using the criteria represented in the parameters.",
 			Tags:        []string{"search"},
 			Responses: openapi3.Responses{
@@ -247,29 +329,51 @@ func (g *Generator) initSwagger() {
 				"403": respErr,
 				"404": respErr,
 			},
-		},
-		Post: &openapi3.Operation{
-			Description: "The create interaction creates a bundle of resources.",
-			Tags:        []string{"create"},
-			RequestBody: &openapi3.RequestBodyRef{
-				Value: &openapi3.RequestBody{
-					Required: true,
-					Content:  contentBundle,
-				},
-			},
-			Responses: responsesBundle,
-		},
-		Put: &openapi3.Operation{
-			Description: "The update interaction creates or updates a bundle of resources.",
-			Tags:        []string{"create", "update"},
-			RequestBody: &openapi3.RequestBodyRef{
-				Value: &openapi3.RequestBody{
-					Required: true,
-					Content:  contentBundle,
-				},
+		}
+	}
+	if g.supports("", InteractionTransaction) || g.supports("", InteractionBatch) {
+		request, respProcessed := g.schemaRef("Bundle"), respBundle
+		if schema := g.systemBundleSchema(false); schema != nil {
+			request = schema
+		}
+		if schema := g.systemBundleSchema(true); schema != nil {
+			respProcessed = &openapi3.ResponseRef{Value: &openapi3.Response{
+				Description: ptr.String("OK"),
+				Content:     openapi3.NewContentWithJSONSchemaRef(schema),
+			}}
+		}
+		root.Post = &openapi3.Operation{
+			Description: "The batch and transaction interactions process the entries of the Bundle and return the Bundle of the entry responses.",
+			Tags:        []string{"batch", "transaction"},
+			RequestBody: NewRequestBodyWithContent(openapi3.NewContentWithJSONSchemaRef(request), true),
+			Responses: openapi3.Responses{
+				"200": respProcessed,
+				"400": respErr,
+				"401": respErr,
+				"403": respErr,
+				"404": respErr,
+				"405": respErr,
+				"409": respErr,
+				"412": respErr,
+				"422": respErr,
 			},
-			Responses: responsesBundle,
-		},
+		}
+	}
+	g.addPathItem("/", root)
+
+	if g.Strict && g.supports("", InteractionSearchSystem) {
+		g.Swagger.Paths["/"+searchPath] = &openapi3.PathItem{Post: g.searchOperation("", respBundle, respErr)}
+	}
+
+	if g.supports("", InteractionHistorySystem) {
+		g.Swagger.Paths["/_history"] = &openapi3.PathItem{Get: g.historyOperation("", respBundle, respErr)}
+	}
+}
+
+// addPathItem adds the path item if it has any operation.
+func (g *Generator) addPathItem(path string, item *openapi3.PathItem) {
+	if len(item.Operations()) > 0 {
+		g.Swagger.Paths[path] = item
 	}
 }
 
@@ -288,18 +392,21 @@ func (g *Generator) convertSchemas(src []*Type) []*openapi3.SchemaRef {
 func (g *Generator) convertNamedSchemas(src map[string]*Type, genOps bool) openapi3.Schemas {
 	var dst openapi3.Schemas
 	if len(src) > 0 {
+		// The properties keep their names, the definitions are named by the naming strategy.
+		componentName := func(name string) string { return name }
+		if genOps {
+			componentName = g.componentName
+		}
 		dst = make(openapi3.Schemas, len(src))
 		for name, schema := range src {
-			if strings.HasPrefix(name, "_") {
-				if _, ok := src[strings.TrimPrefix(name, "_")]; ok && g.SkipUnderscore {
-					continue
-				}
+			if g.isSkipped(name, src) {
+				continue
 			}
 			if len(name) == 0 { // || (strings.HasPrefix(name, "_") && g.SkipUnderscore) {
 				continue
 			}
-			dst[name] = g.convertSchema(schema)
-			if genOps && unicode.IsUpper([]rune(name)[0]) {
+			dst[componentName(name)] = g.convertSchema(g.foldPrimitiveExtension(name, schema, src))
+			if genOps && unicode.IsUpper([]rune(name)[0]) && !g.isGenerated(name) && g.isDeclared(name) && g.isSelected(name) && (!g.Strict || g.isResource(name)) {
 				g.createPathes(name)
 			}
 		}
@@ -314,11 +421,11 @@ func (g *Generator) convertSchema(src *Type) *openapi3.SchemaRef {
 
 	// Use standard OpenAPI types
 	if src.Ref != "" {
-		mapper.Convert(src)
+		g.typeMapper().Convert(src)
 	}
 
 	dst := &openapi3.SchemaRef{
-		Ref: strings.Replace(src.Ref, "#/definitions/", "#/components/schemas/", 1),
+		Ref: g.componentRef(src.Ref),
 		Value: &openapi3.Schema{
 			Type:         src.Type,
 			Description:  src.Description,
@@ -356,26 +463,51 @@ func (g *Generator) convertSchema(src *Type) *openapi3.SchemaRef {
 		dst.Value.Example = src.Examples[0]
 	}
 
+	if src.Discriminator != nil {
+		dst.Value.Discriminator = &openapi3.Discriminator{
+			PropertyName: src.Discriminator.PropertyName,
+			Mapping:      make(map[string]string, len(src.Discriminator.Mapping)),
+		}
+		for value, ref := range src.Discriminator.Mapping {
+			dst.Value.Discriminator.Mapping[value] = g.componentRef(ref)
+		}
+	}
+
+	if len(src.Extras) > 0 {
+		dst.Value.Extensions = src.Extras
+	}
+	g.convertKeywords(src, dst.Value)
+	// Reference siblings are ignored, so the constrained reference is wrapped.
+	if dst.Ref != "" && (len(src.Extras) > 0 || len(src.Enum) > 0) {
+		dst.Value = &openapi3.Schema{
+			ExtensionProps: dst.Value.ExtensionProps,
+			Description:    dst.Value.Description,
+			Enum:           dst.Value.Enum,
+			AllOf:          openapi3.SchemaRefs{openapi3.NewSchemaRef(dst.Ref, nil)},
+		}
+		dst.Ref = ""
+	}
+
 	return dst
 }
 
 func (g *Generator) createPathes(entity string) {
 	// Response
-	g.Swagger.Components.Responses[entity+ResposePostfix] = &openapi3.ResponseRef{Value: &openapi3.Response{
+	g.Swagger.Components.Responses[g.responseName(entity)] = &openapi3.ResponseRef{Value: &openapi3.Response{
 		Description: ptr.String("OK"),
-		Content:     openapi3.NewContentWithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/"+entity, nil)),
+		Content:     openapi3.NewContentWithJSONSchemaRef(g.profileSchema(entity)),
 	}}
 
-	content := openapi3.NewContentWithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/"+entity, nil))
+	content := openapi3.NewContentWithJSONSchemaRef(g.profileSchema(entity))
 	requestBody := &openapi3.RequestBodyRef{
 		Value: &openapi3.RequestBody{
 			Required: true,
 			Content:  content,
 		},
 	}
-	respEntity := &openapi3.ResponseRef{Ref: "#/components/responses/" + entity + ResposePostfix}
+	respEntity := &openapi3.ResponseRef{Ref: "#/components/responses/" + g.responseName(entity)}
 	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
-	respBundle := &openapi3.ResponseRef{Ref: "#/components/responses/Bundle" + ResposePostfix}
+	respBundle := &openapi3.ResponseRef{Ref: "#/components/responses/" + g.responseName("Bundle")}
 	responsesEntity := openapi3.Responses{
 		"200": respEntity,
 		"201": respEntity,
@@ -386,12 +518,18 @@ func (g *Generator) createPathes(entity string) {
 		"409": respErr,
 		"422": respErr,
 	}
+	// The strict mode replaces the default responses by the responses of the interactions.
+	responses := func(interaction string, resp *openapi3.ResponseRef, responses openapi3.Responses) openapi3.Responses {
+		if g.Strict {
+			return interactionResponses(interaction, resp, respErr)
+		}
+		return responses
+	}
 	// GET /<Entity>
-	g.Swagger.Paths["/"+entity] = &openapi3.PathItem{
-		Get: &openapi3.Operation{
-			Parameters: openapi3.Parameters{
-				NewParameterRef("search"),
-			},
+	item := &openapi3.PathItem{}
+	if g.supports(entity, InteractionSearchType) {
+		item.Get = &openapi3.Operation{
+			Parameters:  g.searchParameters(entity),
 			Description: "This searches all resources of a particular type using the criteria represented in the parameters.",
 			Tags:        []string{entity},
 			Responses: openapi3.Responses{
@@ -401,21 +539,41 @@ func (g *Generator) createPathes(entity string) {
 				"403": respErr,
 				"404": respErr,
 			},
-		},
-		Post: &openapi3.Operation{
+		}
+	}
+	if g.supports(entity, InteractionCreate) {
+		item.Post = &openapi3.Operation{
+			Parameters:  g.conditionalCreateParameters(entity),
 			Description: "The create interaction creates a new resource " + entity + " extension",
 			Tags:        []string{entity},
 			RequestBody: requestBody,
-			Responses:   responsesEntity,
-		},
-		Put: &openapi3.Operation{
-			Description: "The update interaction creates or updates a resource " + entity + ".",
+			Responses:   responses(InteractionCreate, respEntity, responsesEntity),
+		}
+	}
+	if g.supportsConditionalUpdate(entity) {
+		item.Put = &openapi3.Operation{
+			Parameters:  g.searchParameters(entity),
+			Description: "The conditional update interaction creates or updates a resource " + entity + " found by the search criteria.",
 			Tags:        []string{entity},
 			RequestBody: requestBody,
-			Responses:   responsesEntity,
-		},
+			Responses:   responses(InteractionUpdate, respEntity, responsesEntity),
+		}
+	}
+	if g.supportsConditionalDelete(entity) {
+		item.Delete = &openapi3.Operation{
+			Parameters:  g.searchParameters(entity),
+			Description: "The conditional delete interaction deletes resources " + entity + " found by the search criteria.",
+			Tags:        []string{entity},
+			Responses:   deleteResponses(respErr),
+		}
 	}
-	g.Swagger.Paths["/"+entity+"/{id}"] = &openapi3.PathItem{
+	g.addPathItem("/"+entity, item)
+
+	if g.Strict && g.supports(entity, InteractionSearchType) {
+		g.Swagger.Paths["/"+entity+"/"+searchPath] = &openapi3.PathItem{Post: g.searchOperation(entity, respBundle, respErr)}
+	}
+
+	item = &openapi3.PathItem{
 		Parameters: openapi3.Parameters{
 			&openapi3.ParameterRef{Value: &openapi3.Parameter{
 				Name:     "id",
@@ -424,61 +582,148 @@ func (g *Generator) createPathes(entity string) {
 				Schema:   openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string"}),
 			}},
 		},
-		Get: &openapi3.Operation{
-			Description: "This searches all resources of a particular type using the criteria represented in the parameters.",
+	}
+	if g.supports(entity, InteractionRead) {
+		item.Get = &openapi3.Operation{
+			Parameters:  g.conditionalReadParameters(entity),
+			Description: "The read interaction accesses the current contents of a resource " + entity + ".",
 			Tags:        []string{entity},
-			Responses: openapi3.Responses{
+			Responses: responses(InteractionRead, respEntity, openapi3.Responses{
 				"200": respEntity,
 				"400": respErr,
 				"401": respErr,
 				"403": respErr,
 				"404": respErr,
-			},
-		},
-		Post: &openapi3.Operation{
+			}),
+		}
+	}
+	// Not a FHIR interaction, kept if there is no capability statement.
+	if g.Capability == nil && !g.Strict {
+		item.Post = &openapi3.Operation{
 			Description: "The create interaction creates a new resource " + entity + " extension",
 			Tags:        []string{entity},
 			RequestBody: requestBody,
 			Responses:   responsesEntity,
-		},
-		Put: &openapi3.Operation{
+		}
+	}
+	if g.supports(entity, InteractionUpdate) {
+		item.Put = &openapi3.Operation{
+			Parameters:  g.versionedUpdateParameters(entity),
 			Description: "The update interaction creates or updates a resource " + entity + ".",
 			Tags:        []string{entity},
 			RequestBody: requestBody,
-			Responses:   responsesEntity,
-		},
-		Patch: &openapi3.Operation{
+			Responses:   responses(InteractionUpdate, respEntity, responsesEntity),
+		}
+	}
+	if g.supports(entity, InteractionPatch) {
+		item.Patch = &openapi3.Operation{
+			Parameters:  g.versionedUpdateParameters(entity),
 			Description: "The patch interaction patches a resource " + entity + ".",
 			Tags:        []string{entity},
-			RequestBody: requestBody,
-			Responses:   responsesEntity,
+			RequestBody: g.patchRequestBody(),
+			Responses:   responses(InteractionPatch, respEntity, responsesEntity),
+		}
+	}
+	if g.supports(entity, InteractionDelete) {
+		item.Delete = &openapi3.Operation{
+			Description: "The delete interaction removes a resource " + entity + ".",
+			Tags:        []string{entity},
+			Responses:   deleteResponses(respErr),
+		}
+	}
+	g.addPathItem("/"+entity+"/{id}", item)
+
+	item = &openapi3.PathItem{
+		Parameters: openapi3.Parameters{
+			NewParameterWithSchema(InPath, "id", true, NewSchemaString()),
+			NewParameterWithSchema(InPath, "vid", true, NewSchemaString()),
 		},
-		Delete: &openapi3.Operation{
-			Description: "The patch interaction patches a resource " + entity + ".",
+	}
+	if g.supports(entity, InteractionVRead) {
+		item.Get = &openapi3.Operation{
+			Description: "The vread interaction reads the version of a resource " + entity + ".",
 			Tags:        []string{entity},
-			// RequestBody: &openapi3.RequestBodyRef{
-			// 	// Value: &openapi3.RequestBody{
-			// 	// 	Required: true,
-			// 	// 	Content:  content,
-			// 	// },
-			// },
-			Responses: openapi3.Responses{
-				"200": &openapi3.ResponseRef{Value: &openapi3.Response{
-					Description: ptr.String("OK"),
-				}},
-				"202": &openapi3.ResponseRef{Value: &openapi3.Response{
-					Description: ptr.String("OK"),
-				}},
-				"204": &openapi3.ResponseRef{Value: &openapi3.Response{
-					Description: ptr.String("OK"),
-				}},
+			Responses: responses(InteractionVRead, respEntity, openapi3.Responses{
+				"200": respEntity,
 				"400": respErr,
+				"401": respErr,
 				"403": respErr,
 				"404": respErr,
-				"405": respErr,
-				"409": respErr,
-				"412": respErr,
-			},
+				"410": respErr,
+			}),
+		}
+	}
+	if g.supports(entity, InteractionDeleteHistoryVersion) {
+		item.Delete = &openapi3.Operation{
+			Description: "The delete history version interaction removes the version of a resource " + entity + " from the history.",
+			Tags:        []string{entity},
+			Responses:   deleteResponses(respErr),
+		}
+	}
+	g.addPathItem("/"+entity+"/{id}/_history/{vid}", item)
+
+	item = &openapi3.PathItem{
+		Parameters: openapi3.Parameters{NewParameterWithSchema(InPath, "id", true, NewSchemaString())},
+	}
+	if g.supports(entity, InteractionHistoryInstance) {
+		item.Get = g.historyOperation(entity, respBundle, respErr)
+	}
+	if g.supports(entity, InteractionDeleteHistory) {
+		item.Delete = &openapi3.Operation{
+			Description: "The delete history interaction removes all versions of a resource " + entity + " except the current one from the history.",
+			Tags:        []string{entity},
+			Responses:   deleteResponses(respErr),
+		}
+	}
+	g.addPathItem("/"+entity+"/{id}/_history", item)
+
+	if g.supports(entity, InteractionHistoryType) {
+		g.Swagger.Paths["/"+entity+"/_history"] = &openapi3.PathItem{Get: g.historyOperation(entity, respBundle, respErr)}
+	}
+}
+
+// historyOperation returns the history interaction of the entity or the system if the entity is empty.
+func (g *Generator) historyOperation(entity string, respBundle, respErr *openapi3.ResponseRef) *openapi3.Operation {
+	tags := []string{"history"}
+	description := "The history interaction retrieves the history of all resources."
+	if entity != "" {
+		tags = []string{entity}
+		description = "The history interaction retrieves the history of resources " + entity + "."
+	}
+	return &openapi3.Operation{
+		Parameters: openapi3.Parameters{
+			NewParameterWithSchema(InQuery, "_count", false, NewSchemaInteger()),
+			NewParameterWithSchema(InQuery, "_since", false, NewSchemaString()),
+			NewParameterWithSchema(InQuery, "_at", false, NewSchemaString()),
+		},
+		Description: description,
+		Tags:        tags,
+		Responses: openapi3.Responses{
+			"200": respBundle,
+			"400": respErr,
+			"401": respErr,
+			"403": respErr,
+			"404": respErr,
 		},
 	}
 }
+
+func deleteResponses(respErr *openapi3.ResponseRef) openapi3.Responses {
+	return openapi3.Responses{
+		"200": &openapi3.ResponseRef{Value: &openapi3.Response{
+			Description: ptr.String("OK"),
+		}},
+		"202": &openapi3.ResponseRef{Value: &openapi3.Response{
+			Description: ptr.String("OK"),
+		}},
+		"204": &openapi3.ResponseRef{Value: &openapi3.Response{
+			Description: ptr.String("OK"),
+		}},
+		"400": respErr,
+		"403": respErr,
+		"404": respErr,
+		"405": respErr,
+		"409": respErr,
+		"412": respErr,
+	}
+}
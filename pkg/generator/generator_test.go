@@ -1,9 +1,210 @@
 package generator
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
+const testSchema = `{
+	"id": "http://hl7.org/fhir/json-schema/4.0",
+	"definitions": {
+		"string": {"type": "string"},
+		"date": {"type": "string"},
+		"dateTime": {"type": "string"},
+		"code": {"type": "string"},
+		"boolean": {"type": "boolean"},
+		"Element": {"properties": {"id": {"$ref": "#/definitions/string"}}},
+		"Narrative": {"properties": {"div": {"$ref": "#/definitions/string"}}},
+		"Reference": {"properties": {"reference": {"$ref": "#/definitions/string"}}},
+		"HumanName": {"properties": {"family": {"$ref": "#/definitions/string"}, "given": {"items": {"$ref": "#/definitions/string"}, "type": "array"}}},
+		"Bundle": {"properties": {"resourceType": {"const": "Bundle"}}, "required": ["resourceType"]},
+		"OperationOutcome": {"properties": {"resourceType": {"const": "OperationOutcome"}}, "required": ["resourceType"]},
+		"Parameters": {"properties": {"resourceType": {"const": "Parameters"}}, "required": ["resourceType"]},
+		"Patient": {
+			"properties": {
+				"resourceType": {"const": "Patient"},
+				"text": {"$ref": "#/definitions/Narrative"},
+				"name": {"items": {"$ref": "#/definitions/HumanName"}, "type": "array"},
+				"gender": {"$ref": "#/definitions/code"},
+				"birthDate": {"$ref": "#/definitions/date"},
+				"_birthDate": {"$ref": "#/definitions/Element"},
+				"deceasedBoolean": {"$ref": "#/definitions/boolean"},
+				"deceasedDateTime": {"$ref": "#/definitions/dateTime"}
+			},
+			"required": ["resourceType"]
+		}
+	}
+}`
+
+const testSearchParameters = `{
+	"resourceType": "Bundle",
+	"entry": [
+		{"resource": {"resourceType": "SearchParameter", "id": "Resource-id", "code": "_id", "base": ["Resource"], "type": "token"}},
+		{"resource": {"resourceType": "SearchParameter", "id": "DomainResource-text", "code": "_text", "base": ["DomainResource"], "type": "string"}},
+		{"resource": {"resourceType": "SearchParameter", "id": "individual-birthdate", "code": "birthdate", "base": ["Patient"], "type": "date"}}
+	]
+}`
+
+const testStructureDefinitions = `{
+	"resourceType": "Bundle",
+	"entry": [
+		{"resource": {
+			"resourceType": "StructureDefinition",
+			"url": "http://hl7.org/fhir/StructureDefinition/boolean",
+			"kind": "primitive-type",
+			"type": "boolean",
+			"description": "Value of \"true\" or \"false\"",
+			"snapshot": {"element": [
+				{"path": "boolean", "min": 0, "max": "*"},
+				{"path": "boolean.value", "min": 0, "max": "1", "type": [{
+					"extension": [{"url": "http://hl7.org/fhir/StructureDefinition/regex", "valueString": "true|false"}],
+					"code": "http://hl7.org/fhirpath/System.Boolean"
+				}]}
+			]}
+		}},
+		{"resource": {
+			"resourceType": "StructureDefinition",
+			"url": "http://hl7.org/fhir/StructureDefinition/Patient",
+			"extension": [{"url": "http://hl7.org/fhir/StructureDefinition/structuredefinition-category", "valueString": "Base.Individuals"}],
+			"kind": "resource",
+			"type": "Patient",
+			"derivation": "specialization",
+			"description": "Demographics and other administrative information about an individual.",
+			"snapshot": {"element": [
+				{"path": "Patient", "min": 0, "max": "*"},
+				{"path": "Patient.id", "min": 0, "max": "1", "type": [{
+					"extension": [{"url": "http://hl7.org/fhir/StructureDefinition/structuredefinition-fhir-type", "valueUrl": "string"}],
+					"code": "http://hl7.org/fhirpath/System.String"
+				}]},
+				{"path": "Patient.text", "min": 0, "max": "1", "type": [{"code": "Narrative"}]},
+				{"path": "Patient.language", "min": 0, "max": "1", "type": [{"code": "code"}], "binding": {"strength": "preferred", "valueSet": "http://hl7.org/fhir/ValueSet/languages"}},
+				{"path": "Patient.gender", "min": 0, "max": "1", "type": [{"code": "code"}], "binding": {"strength": "required", "valueSet": "http://hl7.org/fhir/ValueSet/administrative-gender|4.0.1"}},
+				{"path": "Patient.birthDate", "min": 0, "max": "1", "type": [{"code": "date"}], "isSummary": true},
+				{"path": "Patient.deceased[x]", "min": 0, "max": "1", "type": [{"code": "boolean"}, {"code": "dateTime"}]},
+				{"path": "Patient.generalPractitioner", "min": 0, "max": "*", "type": [{"code": "Reference", "targetProfile": [
+					"http://hl7.org/fhir/StructureDefinition/Practitioner", "http://hl7.org/fhir/StructureDefinition/Organization"
+				]}]},
+				{"path": "Patient.contact", "min": 0, "max": "*", "type": [{"code": "BackboneElement"}]},
+				{"path": "Patient.contact.name", "min": 1, "max": "1", "type": [{"code": "Narrative"}]},
+				{"path": "Patient.contact.contact", "min": 0, "max": "*", "contentReference": "#Patient.contact"}
+			]}
+		}}
+	]
+}`
+
+const testValueSets = `{
+	"resourceType": "Bundle",
+	"entry": [
+		{"resource": {
+			"resourceType": "ValueSet",
+			"url": "http://hl7.org/fhir/ValueSet/administrative-gender",
+			"compose": {"include": [{"system": "http://hl7.org/fhir/administrative-gender"}]}
+		}},
+		{"resource": {
+			"resourceType": "CodeSystem",
+			"url": "http://hl7.org/fhir/administrative-gender",
+			"content": "complete",
+			"concept": [{"code": "male"}, {"code": "female"}, {"code": "other", "concept": [{"code": "unknown"}]}]
+		}}
+	]
+}`
+
+const testProfile = `{
+	"resourceType": "StructureDefinition",
+	"url": "http://example.org/StructureDefinition/us-core-patient",
+	"name": "USCorePatientProfile",
+	"kind": "resource",
+	"type": "Patient",
+	"baseDefinition": "http://hl7.org/fhir/StructureDefinition/Patient",
+	"derivation": "constraint",
+	"differential": {"element": [
+		{"id": "Patient", "path": "Patient"},
+		{"id": "Patient.name", "path": "Patient.name", "min": 1, "mustSupport": true},
+		{"id": "Patient.name.family", "path": "Patient.name.family", "min": 1},
+		{"id": "Patient.gender", "path": "Patient.gender", "patternCode": "female"},
+		{"id": "Patient.birthDate", "path": "Patient.birthDate", "max": "0"},
+		{"id": "Patient.deceased[x]", "path": "Patient.deceased[x]", "type": [{"code": "boolean"}]}
+	]}
+}`
+
+const testOperationDefinitions = `{
+	"resourceType": "Bundle",
+	"entry": [
+		{"resource": {
+			"resourceType": "OperationDefinition",
+			"url": "http://hl7.org/fhir/OperationDefinition/Patient-everything",
+			"name": "Everything",
+			"code": "everything",
+			"resource": ["Patient"],
+			"system": false,
+			"type": true,
+			"instance": true,
+			"parameter": [
+				{"name": "start", "use": "in", "min": 0, "max": "1", "type": "date"},
+				{"name": "_count", "use": "in", "min": 0, "max": "1", "type": "integer"},
+				{"name": "return", "use": "out", "min": 1, "max": "1", "type": "Bundle"}
+			]
+		}},
+		{"resource": {
+			"resourceType": "OperationDefinition",
+			"url": "http://hl7.org/fhir/OperationDefinition/Resource-validate",
+			"name": "Validate",
+			"code": "validate",
+			"resource": ["Resource"],
+			"system": true,
+			"type": true,
+			"instance": true,
+			"parameter": [
+				{"name": "resource", "use": "in", "min": 0, "max": "1", "type": "Resource"},
+				{"name": "return", "use": "out", "min": 1, "max": "1", "type": "OperationOutcome"}
+			]
+		}}
+	]
+}`
+
+const testCapabilityStatement = `{
+	"resourceType": "CapabilityStatement",
+	"rest": [{
+		"mode": "server",
+		"interaction": [{"code": "transaction"}],
+		"resource": [{
+			"type": "Patient",
+			"interaction": [{"code": "read"}, {"code": "search-type"}, {"code": "create"}, {"code": "vread"}],
+			"conditionalCreate": true,
+			"conditionalRead": "full-support",
+			"searchParam": [{"name": "birthdate", "type": "date"}],
+			"operation": [{"name": "everything", "definition": "http://hl7.org/fhir/OperationDefinition/Patient-everything|4.0.1"}]
+		}]
+	}]
+}`
+
+func generate(t *testing.T, g *Generator, schema string) *openapi3.Swagger {
+	t.Helper()
+	var out bytes.Buffer
+	if err := g.Do(strings.NewReader(schema), &out, JSON); err != nil {
+		t.Fatal(err)
+	}
+	swagger, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := swagger.Validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return swagger
+}
+
 func TestNew(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -13,3 +214,1205 @@ func TestNew(t *testing.T) {
 	}()
 	t.Logf("%+v", New())
 }
+
+func TestSearchParameters(t *testing.T) {
+	g := New()
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	param := swagger.Components.Parameters["Patient-birthdate"]
+	if param == nil {
+		t.Fatal("parameter Patient-birthdate is not generated")
+	}
+	if param.Value.Name != "birthdate" || param.Value.In != "query" || param.Value.Schema.Value.Pattern == "" {
+		t.Errorf("unexpected parameter: %+v", param.Value)
+	}
+
+	refs := map[string]bool{}
+	for _, p := range swagger.Paths["/Patient"].Get.Parameters {
+		refs[p.Ref] = true
+	}
+	for _, name := range []string{"_id", "_text", "Patient-birthdate", "_count"} {
+		if !refs["#/components/parameters/"+name] {
+			t.Errorf("GET /Patient has no parameter %s", name)
+		}
+	}
+	if _, ok := swagger.Components.Parameters["search"]; ok {
+		t.Error("generic search parameter is generated")
+	}
+}
+
+func TestStructureDefinitions(t *testing.T) {
+	g := New()
+	g.SkipUnderscore = false
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+	schemas := swagger.Components.Schemas
+
+	if typ := schemas["boolean"].Value; typ.Type != "boolean" || typ.Pattern != "^true|false$" {
+		t.Errorf("unexpected boolean schema: %+v", typ)
+	}
+
+	patient := schemas["Patient"].Value
+	for _, name := range []string{"resourceType", "id", "birthDate", "_birthDate", "deceasedBoolean", "_deceasedBoolean", "deceasedDateTime", "contact"} {
+		if _, ok := patient.Properties[name]; !ok {
+			t.Errorf("Patient has no property %s", name)
+		}
+	}
+	if ref := patient.Properties["contact"].Value.Items.Ref; ref != "#/components/schemas/Patient_Contact" {
+		t.Errorf("unexpected Patient.contact items reference: %s", ref)
+	}
+
+	contact := schemas["Patient_Contact"].Value
+	if len(contact.Required) != 1 || contact.Required[0] != "name" {
+		t.Errorf("unexpected Patient_Contact required properties: %v", contact.Required)
+	}
+	if ref := contact.Properties["contact"].Value.Items.Ref; ref != "#/components/schemas/Patient_Contact" {
+		t.Errorf("unexpected Patient.contact.contact items reference: %s", ref)
+	}
+
+	g = New()
+	if err := g.LoadResources(strings.NewReader(strings.Replace(testStructureDefinitions,
+		`"contentReference": "#Patient.contact"`, `"contentReference": "#Patient.link"`, 1))); err != nil {
+		t.Fatal(err)
+	}
+	err := g.Do(strings.NewReader(testSchema), ioutil.Discard, JSON)
+	if err == nil || !strings.Contains(err.Error(), "#Patient.link") || !strings.Contains(err.Error(), "Patient.contact.contact") {
+		t.Errorf("unresolved content reference is not reported: %v", err)
+	}
+}
+
+func TestLoadPackage(t *testing.T) {
+	files := map[string]string{
+		"package/package.json":                              `{"name": "test.fhir.core", "version": "1.0.0"}`,
+		"package/SearchParameter-individual-birthdate.json": `{"resourceType": "SearchParameter", "id": "individual-birthdate", "code": "birthdate", "base": ["Patient"], "type": "date"}`,
+		"package/example/Patient-example.json":              `{"resourceType": "SearchParameter", "code": "example", "base": ["Patient"], "type": "token"}`,
+	}
+
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+
+		name = filepath.Join(dir, "test.fhir.core#1.0.0", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "package.tgz")
+	if err := ioutil.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pkg := range []string{archive, "test.fhir.core#1.0.0", "test.fhir.core"} {
+		g := New()
+		g.PackageCache = dir
+		if err := g.LoadPackage(pkg); err != nil {
+			t.Fatalf("%s: %s", pkg, err)
+		}
+		if params := g.SearchParameters["Patient"]; len(params) != 1 || params[0].Code != "birthdate" {
+			t.Errorf("%s: unexpected Patient search parameters: %+v", pkg, params)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	g := New()
+	g.Profiles = []string{"*"}
+	g.ProfileBodies = true
+	if err := g.LoadResources(strings.NewReader(testProfile)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	ref := swagger.Components.Schemas["USCorePatientProfile"]
+	if ref == nil {
+		t.Fatal("profile component is not generated")
+	}
+	profile := ref.Value
+	if !reflect.DeepEqual(profile.Required, []string{"resourceType", "name"}) {
+		t.Errorf("unexpected required properties: %v", profile.Required)
+	}
+	for _, name := range []string{"birthDate", "_birthDate", "deceasedDateTime"} {
+		if _, ok := profile.Properties[name]; ok {
+			t.Errorf("removed property %s is generated", name)
+		}
+	}
+	if _, ok := profile.Properties["deceasedBoolean"]; !ok {
+		t.Error("allowed choice variant deceasedBoolean is removed")
+	}
+	name := profile.Properties["name"].Value
+	if _, ok := name.Extensions[mustSupportExt]; !ok {
+		t.Errorf("name is not annotated as must support: %v", name.Extensions)
+	}
+	if !reflect.DeepEqual(name.Items.Value.Required, []string{"family"}) {
+		t.Errorf("unexpected name required properties: %v", name.Items.Value.Required)
+	}
+	if !reflect.DeepEqual(profile.Properties["gender"].Value.Enum, []interface{}{"female"}) {
+		t.Errorf("unexpected gender enum: %v", profile.Properties["gender"].Value.Enum)
+	}
+	if len(swagger.Components.Schemas["HumanName"].Value.Required) != 0 {
+		t.Error("base definition is modified by the profile")
+	}
+
+	if _, ok := swagger.Paths["/USCorePatientProfile"]; ok {
+		t.Error("paths are generated for the profile")
+	}
+	body := swagger.Paths["/Patient"].Post.RequestBody.Value.Content.Get("application/json").Schema
+	if body.Ref != "#/components/schemas/USCorePatientProfile" {
+		t.Errorf("unexpected request body schema: %s", body.Ref)
+	}
+
+	// The profile derived from the versioned canonical URL of the base profile keeps its constraints.
+	g = New()
+	g.Profiles = []string{"local-patient"}
+	if err := g.LoadResources(strings.NewReader(testProfile)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadResources(strings.NewReader(`{
+		"resourceType": "StructureDefinition",
+		"url": "http://example.org/StructureDefinition/local-patient",
+		"name": "local-patient",
+		"kind": "resource",
+		"type": "Patient",
+		"baseDefinition": "http://example.org/StructureDefinition/us-core-patient|1.0.0",
+		"derivation": "constraint",
+		"differential": {"element": [{"id": "Patient.deceased[x]", "path": "Patient.deceased[x]", "max": "0"}]}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	local := generate(t, g, testSchema).Components.Schemas["LocalPatient"]
+	if local == nil {
+		t.Fatal("derived profile component is not generated")
+	}
+	if !reflect.DeepEqual(local.Value.Required, []string{"resourceType", "name"}) {
+		t.Errorf("derived profile drops the base profile constraints: %v", local.Value.Required)
+	}
+	if _, ok := local.Value.Properties["deceasedBoolean"]; ok {
+		t.Error("removed choice element deceased[x] is generated")
+	}
+}
+
+func TestOperationDefinitions(t *testing.T) {
+	g := New()
+	if err := g.LoadResources(strings.NewReader(testOperationDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	everything := swagger.Paths["/Patient/$everything"]
+	if everything == nil || everything.Get == nil || everything.Post == nil {
+		t.Fatal("type level operation $everything is not generated")
+	}
+	if len(everything.Get.Parameters) != 2 || everything.Get.Parameters[0].Value.Name != "start" {
+		t.Errorf("unexpected $everything query parameters: %+v", everything.Get.Parameters)
+	}
+	instance := swagger.Paths["/Patient/{id}/$everything"]
+	if instance == nil {
+		t.Fatal("instance level operation $everything is not generated")
+	}
+	if ref := instance.Post.Responses["200"].Value.Content.Get("application/json").Schema.Ref; ref != "#/components/schemas/Bundle" {
+		t.Errorf("unexpected $everything output: %s", ref)
+	}
+
+	for _, path := range []string{"/$validate", "/Patient/$validate", "/Bundle/{id}/$validate"} {
+		item := swagger.Paths[path]
+		if item == nil {
+			t.Errorf("operation %s is not generated", path)
+			continue
+		}
+		if item.Get != nil {
+			t.Errorf("operation %s with resource input parameter has GET", path)
+		}
+		if ref := item.Post.RequestBody.Value.Content.Get("application/json").Schema.Ref; ref != "#/components/schemas/Parameters" {
+			t.Errorf("unexpected %s input: %s", path, ref)
+		}
+	}
+}
+
+func TestCapabilityStatement(t *testing.T) {
+	g := New()
+	if err := g.LoadResources(strings.NewReader(testOperationDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadCapabilityStatement(strings.NewReader(testCapabilityStatement)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	for _, path := range []string{"/Bundle", "/Bundle/{id}", "/$validate", "/Patient/$validate"} {
+		if _, ok := swagger.Paths[path]; ok {
+			t.Errorf("undeclared path %s is generated", path)
+		}
+	}
+	root := swagger.Paths["/"]
+	if root == nil || root.Get != nil || root.Put != nil || root.Post == nil {
+		t.Errorf("unexpected root path: %+v", root)
+	}
+
+	patient := swagger.Paths["/Patient"]
+	if patient == nil || patient.Get == nil || patient.Post == nil || patient.Put != nil {
+		t.Fatalf("unexpected /Patient path: %+v", patient)
+	}
+	refs := map[string]bool{}
+	for _, p := range patient.Get.Parameters {
+		refs[p.Ref] = true
+	}
+	if !refs["#/components/parameters/Patient-birthdate"] || refs["#/components/parameters/_text"] {
+		t.Errorf("unexpected GET /Patient parameters: %v", refs)
+	}
+	if params := patient.Post.Parameters; len(params) != 1 || params[0].Value.Name != "If-None-Exist" || params[0].Value.In != "header" {
+		t.Errorf("unexpected POST /Patient parameters: %+v", params)
+	}
+
+	instance := swagger.Paths["/Patient/{id}"]
+	if instance == nil || instance.Get == nil || instance.Put != nil || instance.Patch != nil || instance.Delete != nil || instance.Post != nil {
+		t.Fatalf("unexpected /Patient/{id} path: %+v", instance)
+	}
+	if len(instance.Get.Parameters) != 2 {
+		t.Errorf("unexpected GET /Patient/{id} parameters: %+v", instance.Get.Parameters)
+	}
+	if _, ok := swagger.Paths["/Patient/{id}/_history/{vid}"]; !ok {
+		t.Error("vread is not generated")
+	}
+	if _, ok := swagger.Paths["/Patient/{id}/$everything"]; !ok {
+		t.Error("declared operation $everything is not generated")
+	}
+
+	capability := strings.Replace(testCapabilityStatement, `"conditionalCreate": true,`, `"conditionalCreate": true, "conditionalUpdate": true,`, 1)
+	capability = strings.Replace(capability, `{"code": "vread"}`, `{"code": "vread"}, {"code": "update"}`, 1)
+	g = New()
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadCapabilityStatement(strings.NewReader(capability)); err != nil {
+		t.Fatal(err)
+	}
+	put := generate(t, g, testSchema).Paths["/Patient"].Put
+	if put == nil {
+		t.Fatal("conditional update is not generated")
+	}
+	refs = map[string]bool{}
+	for _, p := range put.Parameters {
+		refs[p.Ref] = true
+	}
+	if !refs["#/components/parameters/Patient-birthdate"] {
+		t.Errorf("conditional update has no search criteria: %v", refs)
+	}
+	if !strings.Contains(put.Description, "conditional update") {
+		t.Errorf("unexpected conditional update description: %s", put.Description)
+	}
+}
+
+func TestValueSets(t *testing.T) {
+	g := New()
+	for _, resources := range []string{testStructureDefinitions, testValueSets} {
+		if err := g.LoadResources(strings.NewReader(resources)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	swagger := generate(t, g, testSchema)
+	patient := swagger.Components.Schemas["Patient"].Value
+
+	gender := patient.Properties["gender"].Value
+	if want := []interface{}{"female", "male", "other", "unknown"}; !reflect.DeepEqual(gender.Enum, want) || gender.Type != "string" {
+		t.Errorf("unexpected gender schema: %+v", gender)
+	}
+	language := patient.Properties["language"]
+	if _, ok := language.Value.Extensions[bindingExt]; !ok {
+		t.Errorf("language binding is not annotated: %v", language.Value.Extensions)
+	}
+	if len(language.Value.Enum) != 0 {
+		t.Errorf("preferred binding is converted into enum: %v", language.Value.Enum)
+	}
+
+	g = New()
+	g.PrimitiveExtensions = PrimitiveExtensionsKeep
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	ext := generate(t, g, testSchema).Components.Schemas["Patient"].Value.Properties["_gender"]
+	if ext == nil {
+		t.Fatal("_gender is not generated")
+	}
+	if _, ok := ext.Value.Extensions[bindingExt]; ok {
+		t.Errorf("extensions property _gender is bound: %v", ext.Value.Extensions)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	schema := strings.Replace(testSchema, `json-schema/4.0",
+	"definitions": {`, `json-schema/5.0",
+	"definitions": {
+		"integer64": {"type": "string", "pattern": "^[0]|[-+]?[1-9][0-9]*$"},`, 1)
+	schema = strings.Replace(schema, `"resourceType": {"const": "Patient"},`, `"resourceType": {"const": "Patient"},
+				"count": {"$ref": "#/definitions/integer64"},`, 1)
+
+	g := New()
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadCapabilityStatement(strings.NewReader(strings.Replace(testCapabilityStatement,
+		`{"code": "vread"}`, `{"code": "vread"}, {"code": "delete-history-version"}`, 1))); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, schema)
+	if g.Version != R5 {
+		t.Errorf("unexpected detected version: %s", g.Version)
+	}
+	if typ := swagger.Components.Schemas["Patient"].Value.Properties["count"].Value.Type; typ != "string" {
+		t.Errorf("unexpected integer64 type: %s", typ)
+	}
+	if item := swagger.Paths["/Patient/{id}/_history/{vid}"]; item == nil || item.Delete == nil {
+		t.Error("R5 delete history version interaction is not generated")
+	}
+
+	g = New()
+	g.Version = STU3
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	swagger = generate(t, g, testSchema)
+	if _, ok := swagger.Components.Parameters["_total"]; ok {
+		t.Error("_total search parameter is generated for STU3")
+	}
+}
+
+func TestSTU3Resources(t *testing.T) {
+	sds := strings.Replace(testStructureDefinitions, `"targetProfile": [
+					"http://hl7.org/fhir/StructureDefinition/Practitioner", "http://hl7.org/fhir/StructureDefinition/Organization"
+				]`, `"targetProfile": "http://hl7.org/fhir/StructureDefinition/Practitioner"`, 1)
+	sds = strings.Replace(sds, `"valueSet": "http://hl7.org/fhir/ValueSet/languages"`, `"valueSetUri": "http://hl7.org/fhir/ValueSet/languages"`, 1)
+	sds = strings.Replace(sds, `"valueSet": "http://hl7.org/fhir/ValueSet/administrative-gender|4.0.1"`,
+		`"valueSetReference": {"reference": "http://hl7.org/fhir/ValueSet/administrative-gender"}`, 1)
+	capability := strings.Replace(testCapabilityStatement, `"type": "Patient",`, `"type": "Patient",
+			"profile": {"reference": "http://hl7.org/fhir/StructureDefinition/Patient"},`, 1)
+	capability = strings.Replace(capability, `"definition": "http://hl7.org/fhir/OperationDefinition/Patient-everything|4.0.1"`,
+		`"definition": {"reference": "http://hl7.org/fhir/OperationDefinition/Patient-everything"}`, 1)
+
+	g := New()
+	g.Version = STU3
+	if err := g.LoadResources(strings.NewReader(sds)); err != nil {
+		t.Fatal(err)
+	}
+	for _, resources := range []string{testOperationDefinitions, testValueSets} {
+		if err := g.LoadResources(strings.NewReader(resources)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.LoadCapabilityStatement(strings.NewReader(capability)); err != nil {
+		t.Fatal(err)
+	}
+	if profile := g.serverRest().Resource[0].Profile; profile != "http://hl7.org/fhir/StructureDefinition/Patient" {
+		t.Errorf("unexpected resource profile: %s", profile)
+	}
+	swagger := generate(t, g, testSchema)
+
+	prop := swagger.Components.Schemas["Patient"].Value.Properties["generalPractitioner"].Value
+	if _, ok := prop.Extensions[referenceTargetsExt]; !ok {
+		t.Errorf("reference targets are not annotated: %v", prop.Extensions)
+	}
+	if _, ok := swagger.Paths["/Patient/{id}/$everything"]; !ok {
+		t.Error("declared operation is not generated")
+	}
+	patient := swagger.Components.Schemas["Patient"].Value
+	if gender := patient.Properties["gender"].Value; len(gender.Enum) != 4 {
+		t.Errorf("STU3 value set reference is not expanded: %+v", gender)
+	}
+	if language := patient.Properties["language"].Value; language.Extensions[bindingExt] == nil {
+		t.Errorf("STU3 value set URI is not annotated: %v", language.Extensions)
+	}
+}
+
+func TestParseFHIRVersion(t *testing.T) {
+	for s, want := range map[string]FHIRVersion{"STU3": STU3, "r4": R4, "4.0.1": R4, "R4B": R4B, "4.3.0": R4B, "5.0": R5} {
+		if v, err := ParseFHIRVersion(s); err != nil || v != want {
+			t.Errorf("%s: unexpected version %s, %v", s, v, err)
+		}
+	}
+	if _, err := ParseFHIRVersion("1.0.2"); err == nil {
+		t.Error("unsupported version is parsed")
+	}
+}
+
+func TestResourceDiscriminator(t *testing.T) {
+	schema := strings.Replace(testSchema, `"definitions": {`, `"discriminator": {"propertyName": "resourceType", "mapping": {"Patient": "#/definitions/Patient"}},
+	"definitions": {
+		"ResourceList": {"oneOf": [{"$ref": "#/definitions/Bundle"}, {"$ref": "#/definitions/Patient"}]},`, 1)
+	swagger := generate(t, New(), schema)
+
+	d := swagger.Components.Schemas["ResourceList"].Value.Discriminator
+	if d == nil {
+		t.Fatal("resource list discriminator is not generated")
+	}
+	want := map[string]string{"Bundle": "#/components/schemas/Bundle", "Patient": "#/components/schemas/Patient"}
+	if d.PropertyName != "resourceType" || !reflect.DeepEqual(d.Mapping, want) {
+		t.Errorf("unexpected discriminator: %+v", d)
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	schema := strings.Replace(testSchema, `"definitions": {`, `"definitions": {
+		"Meta": {
+			"properties": {"tag": {"$ref": "#/definitions/code"}},
+			"additionalProperties": {"$ref": "#/definitions/HumanName"},
+			"patternProperties": {"^x-": {"$ref": "#/definitions/string"}},
+			"not": {"required": ["id"]}
+		},`, 1)
+	schema = strings.Replace(schema, `"required": ["resourceType"]
+		}
+	}`, `"required": ["resourceType"],
+			"additionalProperties": false
+		}
+	}`, 1)
+
+	g := New()
+	g.SkipUnderscore = false
+	swagger := generate(t, g, schema)
+	if allowed := swagger.Components.Schemas["Patient"].Value.AdditionalPropertiesAllowed; allowed == nil || *allowed {
+		t.Errorf("Patient is not closed: %v", allowed)
+	}
+	meta := swagger.Components.Schemas["Meta"].Value
+	if meta.AdditionalProperties == nil || meta.AdditionalProperties.Ref != "#/components/schemas/HumanName" {
+		t.Errorf("unexpected Meta additional properties: %+v", meta.AdditionalProperties)
+	}
+	if meta.Not == nil || !reflect.DeepEqual(meta.Not.Value.Required, []string{"id"}) {
+		t.Errorf("unexpected Meta not: %+v", meta.Not)
+	}
+	if _, ok := meta.Extensions[patternPropertiesExt]; !ok {
+		t.Errorf("Meta pattern properties are dropped: %v", meta.Extensions)
+	}
+	if len(g.Warnings) != 1 || !strings.Contains(g.Warnings[0], "Meta: patternProperties") {
+		t.Errorf("unexpected warnings: %v", g.Warnings)
+	}
+
+	g = New()
+	swagger = generate(t, g, schema)
+	if swagger.Components.Schemas["Patient"].Value.AdditionalPropertiesAllowed != nil {
+		t.Error("Patient with skipped underscore properties is closed")
+	}
+	if len(g.Warnings) != 1 {
+		t.Errorf("open definitions are reported without the verbose warnings: %v", g.Warnings)
+	}
+
+	g = New()
+	g.Verbose = true
+	generate(t, g, schema)
+	if len(g.Warnings) != 2 || !strings.HasPrefix(g.Warnings[1], "Patient: additionalProperties") {
+		t.Errorf("unexpected verbose warnings: %v", g.Warnings)
+	}
+}
+
+func TestPrimitiveExtensions(t *testing.T) {
+	for _, strategy := range []PrimitiveExtensionStrategy{PrimitiveExtensionsDrop, PrimitiveExtensionsKeep, PrimitiveExtensionsFold} {
+		g := New()
+		g.PrimitiveExtensions = strategy
+		patient := generate(t, g, testSchema).Components.Schemas["Patient"].Value
+
+		ext, kept := patient.Properties["_birthDate"]
+		if kept != (strategy == PrimitiveExtensionsKeep) {
+			t.Errorf("%s: unexpected _birthDate presence: %t", strategy, kept)
+		}
+		if kept {
+			if _, ok := ext.Value.Extensions[goNameExt]; !ok {
+				t.Errorf("%s: _birthDate has no Go name: %v", strategy, ext.Value.Extensions)
+			}
+		}
+		_, folded := patient.Properties["birthDate"].Value.Extensions[primitiveExtensionExt]
+		if folded != (strategy == PrimitiveExtensionsFold) {
+			t.Errorf("%s: unexpected birthDate annotation presence: %t", strategy, folded)
+		}
+	}
+}
+
+func TestChoices(t *testing.T) {
+	schema := strings.Replace(testSchema, `"Parameters": {`,
+		`"Task": {"properties": {"resourceType": {"const": "Task"}, "reasonCode": {"$ref": "#/definitions/code"}, "reasonReference": {"$ref": "#/definitions/Reference"}}, "required": ["resourceType"]},
+		"Parameters": {`, 1)
+
+	g := New()
+	g.ChoiceConstraints = true
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	schemas := generate(t, g, schema).Components.Schemas
+	patient := schemas["Patient"].Value
+
+	if _, ok := patient.Extensions[choiceExt]; !ok {
+		t.Errorf("choices are not annotated: %v", patient.Extensions)
+	}
+	if len(patient.AllOf) != 1 {
+		t.Fatalf("unexpected constraints: %+v", patient.AllOf)
+	}
+	oneOf := patient.AllOf[0].Value.OneOf
+	if len(oneOf) != 3 || !reflect.DeepEqual(oneOf[0].Value.Required, []string{"deceasedBoolean"}) || oneOf[2].Value.Not == nil {
+		t.Errorf("unexpected deceased[x] constraint: %+v", oneOf)
+	}
+
+	// The choices recognized by the naming convention are annotated only.
+	task := schemas["Task"].Value
+	if _, ok := task.Extensions[choiceExt]; !ok {
+		t.Errorf("Task choices are not annotated: %v", task.Extensions)
+	}
+	if len(task.AllOf) != 0 {
+		t.Errorf("Task is constrained by the naming convention: %+v", task.AllOf)
+	}
+	err := task.VisitJSON(map[string]interface{}{
+		"resourceType":    "Task",
+		"reasonCode":      "overdue",
+		"reasonReference": map[string]interface{}{"reference": "Patient/1"},
+	})
+	if err != nil {
+		t.Errorf("valid Task is rejected: %v", err)
+	}
+
+	g = New()
+	g.ChoiceConstraints = true
+	if patient := generate(t, g, testSchema).Components.Schemas["Patient"].Value; len(patient.AllOf) != 0 {
+		t.Errorf("choices without the element definitions are constrained: %+v", patient.AllOf)
+	}
+}
+
+func TestReferenceTypes(t *testing.T) {
+	g := New()
+	g.ReferenceTypes = true
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	prop := swagger.Components.Schemas["Patient"].Value.Properties["generalPractitioner"].Value
+	if _, ok := prop.Extensions[referenceTargetsExt]; !ok {
+		t.Errorf("reference targets are not annotated: %v", prop.Extensions)
+	}
+	if ref := prop.Items.Ref; ref != "#/components/schemas/Reference_Organization_Practitioner" {
+		t.Fatalf("unexpected reference type: %s", ref)
+	}
+	typ := swagger.Components.Schemas["Reference_Organization_Practitioner"].Value
+	if pattern := typ.AllOf[1].Value.Properties["reference"].Value.Pattern; pattern != "^(Organization|Practitioner)/" {
+		t.Errorf("unexpected reference pattern: %s", pattern)
+	}
+	if _, ok := swagger.Paths["/Reference_Organization_Practitioner"]; ok {
+		t.Error("paths are generated for the reference type")
+	}
+}
+
+func TestPrimitiveMapping(t *testing.T) {
+	schema := strings.Replace(testSchema, `"date": {"type": "string"}`, `"date": {"type": "string", "pattern": "^[0-9]{4}(-[0-9]{2}(-[0-9]{2})?)?$"}`, 1)
+
+	patient := generate(t, New(), schema).Components.Schemas["Patient"].Value
+	if birthDate := patient.Properties["birthDate"].Value; birthDate.Format != "date" {
+		t.Errorf("unexpected lossy date: %+v", birthDate)
+	}
+
+	g := New()
+	g.PrimitiveMapping = FaithfulMapping
+	patient = generate(t, g, schema).Components.Schemas["Patient"].Value
+	if birthDate := patient.Properties["birthDate"].Value; birthDate.Format != "" || birthDate.Type != "string" || birthDate.Pattern == "" {
+		t.Errorf("unexpected faithful date: %+v", birthDate)
+	}
+	if deceased := patient.Properties["deceasedBoolean"].Value; deceased.Type != "boolean" || deceased.Pattern != "" {
+		t.Errorf("unexpected faithful boolean: %+v", deceased)
+	}
+}
+
+func TestTypeMapping(t *testing.T) {
+	schema := strings.Replace(testSchema, `"date": {"type": "string"},`, `"date": {"type": "string"},
+		"time": {"type": "string"},
+		"positiveInt": {"type": "number"},`, 1)
+	schema = strings.Replace(schema, `"resourceType": {"const": "Patient"},`, `"resourceType": {"const": "Patient"},
+				"multipleBirthInteger": {"$ref": "#/definitions/positiveInt"},
+				"visitTime": {"$ref": "#/definitions/time", "pattern": "^[0-9]{2}"},`, 1)
+
+	g := New()
+	err := g.LoadTypeMapping(strings.NewReader(`
+date:
+  type: string
+  pattern: "^[0-9]{4}"
+  x-go-type: string
+positiveInt:
+  type: integer
+  minimum: 1
+  maximum: 5
+time:
+  type: string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patient := generate(t, g, schema).Components.Schemas["Patient"].Value
+
+	birthDate := patient.Properties["birthDate"].Value
+	if birthDate.Format != "" || birthDate.Pattern != "^[0-9]{4}" {
+		t.Errorf("unexpected mapped date: %+v", birthDate)
+	}
+	if _, ok := birthDate.Extensions["x-go-type"]; !ok {
+		t.Errorf("mapped date has no extension: %v", birthDate.Extensions)
+	}
+	if deceased := patient.Properties["deceasedDateTime"].Value; deceased.Format != "date-time" {
+		t.Errorf("unexpected default dateTime mapping: %+v", deceased)
+	}
+	multipleBirth := patient.Properties["multipleBirthInteger"].Value
+	if multipleBirth.Type != "integer" || multipleBirth.Min == nil || *multipleBirth.Min != 1 ||
+		multipleBirth.Max == nil || *multipleBirth.Max != 5 {
+		t.Errorf("unexpected mapped positiveInt: %+v", multipleBirth)
+	}
+	if visitTime := patient.Properties["visitTime"].Value; visitTime.Pattern != "" {
+		t.Errorf("mapped time keeps the inherited pattern: %s", visitTime.Pattern)
+	}
+}
+
+func TestElementMetadata(t *testing.T) {
+	g := New()
+	g.ElementMetadata = true
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	schemas := generate(t, g, testSchema).Components.Schemas
+
+	if _, ok := schemas["Patient_Contact"].Value.Extensions[pathExt]; !ok {
+		t.Errorf("backbone element has no path: %v", schemas["Patient_Contact"].Value.Extensions)
+	}
+	birthDate := schemas["Patient"].Value.Properties["birthDate"].Value
+	for _, ext := range []string{pathExt, minExt, maxExt, isSummaryExt} {
+		if _, ok := birthDate.Extensions[ext]; !ok {
+			t.Errorf("birthDate has no %s: %v", ext, birthDate.Extensions)
+		}
+	}
+	if _, ok := birthDate.Extensions[isModifierExt]; ok {
+		t.Error("birthDate is annotated as modifier")
+	}
+	if _, ok := schemas["Patient"].Value.Properties["gender"].Value.Extensions[bindingExt]; !ok {
+		t.Error("gender has no binding")
+	}
+}
+
+func TestTreeShaking(t *testing.T) {
+	g := New()
+	g.Include = []string{"Pat*"}
+	swagger := generate(t, g, testSchema)
+
+	for _, path := range []string{"/Patient", "/Patient/{id}", "/"} {
+		if _, ok := swagger.Paths[path]; !ok {
+			t.Errorf("path %s is not generated", path)
+		}
+	}
+	for _, path := range []string{"/Bundle", "/HumanName", "/Parameters/{id}"} {
+		if _, ok := swagger.Paths[path]; ok {
+			t.Errorf("path %s of the not selected entity is generated", path)
+		}
+	}
+	for _, name := range []string{"Patient", "HumanName", "Narrative", "Bundle", "OperationOutcome"} {
+		if _, ok := swagger.Components.Schemas[name]; !ok {
+			t.Errorf("reachable schema %s is pruned", name)
+		}
+	}
+	if _, ok := swagger.Components.Schemas["Reference"]; ok {
+		t.Error("unreachable schema Reference is not pruned")
+	}
+	if _, ok := swagger.Components.Responses["Parameters"+ResposePostfix]; ok {
+		t.Error("unreachable response is not pruned")
+	}
+
+	g = New()
+	g.Exclude = []string{"Bundle"}
+	swagger = generate(t, g, testSchema)
+	if _, ok := swagger.Paths["/Bundle"]; ok {
+		t.Error("path of the excluded resource is generated")
+	}
+	if _, ok := swagger.Paths["/Patient"]; !ok {
+		t.Error("path of the not excluded resource is not generated")
+	}
+
+	g = New()
+	g.Categories = []string{"base"}
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	swagger = generate(t, g, testSchema)
+	if _, ok := swagger.Paths["/Patient"]; !ok {
+		t.Error("path of the resource of the selected category is not generated")
+	}
+	if _, ok := swagger.Paths["/Bundle"]; ok {
+		t.Error("path of the resource of the other category is generated")
+	}
+}
+
+func TestCycles(t *testing.T) {
+	schema := strings.Replace(testSchema,
+		`"Reference": {"properties": {"reference": {"$ref": "#/definitions/string"}}},`,
+		`"Reference": {"properties": {"reference": {"$ref": "#/definitions/string"}, "identifier": {"$ref": "#/definitions/Identifier"}}, "required": ["identifier"]},
+		"Identifier": {"properties": {"assigner": {"$ref": "#/definitions/Reference"}}, "required": ["assigner"]},`, 1)
+
+	g := New()
+	generate(t, g, schema)
+	if !reflect.DeepEqual(g.Cycles, [][]string{{"Identifier", "Reference"}}) {
+		t.Errorf("unexpected cycles: %v", g.Cycles)
+	}
+	if len(g.Warnings) != 0 {
+		t.Errorf("cycles are reported without the analysis: %v", g.Warnings)
+	}
+
+	g = New()
+	g.ReportCycles = true
+	generate(t, g, schema)
+	if len(g.Warnings) != 3 || !strings.HasPrefix(g.Warnings[0], "reference cycle through 2 components") {
+		t.Errorf("unexpected warnings: %v", g.Warnings)
+	}
+
+	g = New()
+	g.BreakCycles = true
+	schemas := generate(t, g, schema).Components.Schemas
+	for name, prop := range map[string]string{"Identifier": "assigner", "Reference": "identifier"} {
+		value := schemas[name].Value.Properties[prop].Value
+		if !value.Nullable || len(value.AllOf) != 1 {
+			t.Errorf("%s.%s is not broken: %+v", name, prop, value)
+		}
+		if _, ok := value.Extensions[cycleBreakExt]; !ok {
+			t.Errorf("%s.%s is not annotated: %v", name, prop, value.Extensions)
+		}
+	}
+	if len(g.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", g.Warnings)
+	}
+
+	// The allOf composition is broken by the property of the cycle, the cycle of the compositions alone is reported.
+	schema = strings.Replace(testSchema,
+		`"Reference": {"properties": {"reference": {"$ref": "#/definitions/string"}}},`,
+		`"Reference": {"allOf": [{"$ref": "#/definitions/Identifier"}]},
+		"Identifier": {"properties": {"assigner": {"$ref": "#/definitions/Reference"}}, "required": ["assigner"]},
+		"Age": {"allOf": [{"$ref": "#/definitions/Quantity"}]},
+		"Quantity": {"allOf": [{"$ref": "#/definitions/Age"}]},`, 1)
+	g = New()
+	g.BreakCycles = true
+	schemas = generate(t, g, schema).Components.Schemas
+	if value := schemas["Identifier"].Value.Properties["assigner"].Value; !value.Nullable {
+		t.Errorf("Identifier.assigner is not broken: %+v", value)
+	}
+	if !reflect.DeepEqual(g.Warnings, []string{
+		"Age refers to Quantity by value in the reference cycle",
+		"Quantity refers to Age by value in the reference cycle",
+	}) {
+		t.Errorf("unexpected warnings: %v", g.Warnings)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	g := New()
+	g.Naming = NamingPascalCase
+	g.NamePrefix = "Fhir"
+	if err := g.LoadNameMapping(strings.NewReader("HumanName: Name")); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadResources(strings.NewReader(testStructureDefinitions)); err != nil {
+		t.Fatal(err)
+	}
+	swagger := generate(t, g, testSchema)
+
+	for _, name := range []string{"FhirPatient", "FhirPatientContact", "Name"} {
+		if _, ok := swagger.Components.Schemas[name]; !ok {
+			t.Errorf("component %s is not generated", name)
+		}
+	}
+	for _, name := range []string{"Patient", "Patient_Contact", "HumanName"} {
+		if _, ok := swagger.Components.Schemas[name]; ok {
+			t.Errorf("component %s is not renamed", name)
+		}
+	}
+	patient := swagger.Components.Schemas["FhirPatient"].Value
+	if ref := patient.Properties["contact"].Value.Items.Ref; ref != "#/components/schemas/FhirPatientContact" {
+		t.Errorf("unexpected contact reference: %s", ref)
+	}
+	if _, ok := swagger.Components.Responses["FhirPatient"+ResposePostfix]; !ok {
+		t.Error("response is not renamed")
+	}
+	if _, ok := swagger.Paths["/Patient"]; !ok {
+		t.Error("path is renamed")
+	}
+
+	g = New()
+	g.NameMapping["Narrative"] = "HumanName"
+	if err := g.Do(strings.NewReader(testSchema), ioutil.Discard, JSON); err == nil || !strings.Contains(err.Error(), "collides") {
+		t.Errorf("collision is not detected: %v", err)
+	}
+}
+
+func TestExamples(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"patient-example.json":   `{"resourceType": "Patient", "id": "example", "gender": "male"}`,
+		"patient-example-b.json": `{"resourceType": "Patient", "id": "b", "gender": "female"}`,
+		"patient-no-id.json":     `{"resourceType": "Patient"}`,
+		"notes.json":             `{"note": "not a resource"}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := New()
+	if err := g.LoadExamples(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Examples) != 1 || len(g.Examples["Patient"]) != 3 {
+		t.Fatalf("unexpected examples: %v", g.Examples)
+	}
+	swagger := generate(t, g, testSchema)
+
+	if swagger.Components.Schemas["Patient"].Value.Example == nil {
+		t.Error("Patient has no example")
+	}
+	examples := swagger.Components.Responses["Patient"+ResposePostfix].Value.Content.Get("application/json").Examples
+	for _, name := range []string{"example", "b", "patient-no-id"} {
+		if _, ok := examples[name]; !ok {
+			t.Errorf("response has no example %s: %v", name, examples)
+		}
+	}
+	body := swagger.Paths["/Patient"].Post.RequestBody.Value.Content.Get("application/json")
+	if len(body.Examples) != 3 {
+		t.Errorf("unexpected request body examples: %v", body.Examples)
+	}
+
+	g = New()
+	g.MaxExamples = 1
+	if err := g.LoadExamples(dir); err != nil {
+		t.Fatal(err)
+	}
+	examples = generate(t, g, testSchema).Components.Responses["Patient"+ResposePostfix].Value.Content.Get("application/json").Examples
+	if _, ok := examples["example"]; !ok || len(examples) != 1 {
+		t.Errorf("unexpected limited examples: %v", examples)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	g := New()
+	g.Strict = true
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	paths := generate(t, g, testSchema).Paths
+
+	for _, path := range []string{"/Patient/{id}/_history/{vid}", "/Patient/{id}/_history", "/Patient/_history", "/_history", "/Patient/_search", "/_search"} {
+		if paths[path] == nil || paths[path].Get == nil && paths[path].Post == nil {
+			t.Errorf("path %s is not generated", path)
+		}
+	}
+	if _, ok := paths["/HumanName"]; ok {
+		t.Error("path of the data type is generated")
+	}
+	if paths["/"].Put != nil || paths["/Patient/{id}"].Post != nil {
+		t.Error("not FHIR interactions are generated")
+	}
+	form := paths["/Patient/_search"].Post.RequestBody.Value.Content[formContentType]
+	if form == nil || form.Schema.Value.Properties["birthdate"] == nil {
+		t.Errorf("unexpected search form: %+v", form)
+	}
+
+	headers := func(op *openapi3.Operation) map[string]bool {
+		names := map[string]bool{}
+		for _, p := range op.Parameters {
+			if p.Value != nil && p.Value.In == "header" {
+				names[p.Value.Name] = true
+			}
+		}
+		return names
+	}
+	patient := paths["/Patient"]
+	if !headers(patient.Post)["If-None-Exist"] || patient.Delete == nil || len(patient.Put.Parameters) == 0 {
+		t.Error("conditional interactions are not generated")
+	}
+	instance := paths["/Patient/{id}"]
+	if h := headers(instance.Get); !h["If-Modified-Since"] || !h["If-None-Match"] {
+		t.Errorf("unexpected read headers: %v", h)
+	}
+	if !headers(instance.Put)["If-Match"] || !headers(instance.Patch)["If-Match"] {
+		t.Error("update has no If-Match header")
+	}
+	if _, ok := instance.Get.Responses["410"]; !ok {
+		t.Errorf("unexpected read responses: %v", instance.Get.Responses)
+	}
+	if _, ok := instance.Patch.Responses["201"]; ok {
+		t.Errorf("unexpected patch responses: %v", instance.Patch.Responses)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	g := New()
+	swagger := generate(t, g, testSchema)
+
+	if ref := swagger.Paths["/Patient/{id}"].Patch.RequestBody.Ref; ref != "#/components/requestBodies/"+patchRequestBodyName {
+		t.Fatalf("unexpected patch body: %s", ref)
+	}
+	content := swagger.Components.RequestBodies[patchRequestBodyName].Value.Content
+	if media := content[JSONPatchContentType]; media == nil || media.Schema.Ref != "#/components/schemas/"+jsonPatchName {
+		t.Errorf("unexpected JSON Patch body: %+v", media)
+	}
+	if media := content[FHIRContentType]; media == nil || media.Schema.Ref != "#/components/schemas/Parameters" {
+		t.Errorf("unexpected FHIRPath Patch body: %+v", media)
+	}
+	if _, ok := content[MergePatchContentType]; ok {
+		t.Error("merge patch body is generated")
+	}
+	patch := swagger.Components.Schemas[jsonPatchName].Value
+	if patch.Type != "array" || patch.Items.Ref != "#/components/schemas/"+jsonPatchOperationName {
+		t.Errorf("unexpected JSON Patch schema: %+v", patch)
+	}
+
+	g = New()
+	g.MergePatch = true
+	content = generate(t, g, testSchema).Components.RequestBodies[patchRequestBodyName].Value.Content
+	if _, ok := content[MergePatchContentType]; !ok {
+		t.Error("merge patch body is not generated")
+	}
+
+	g = New()
+	g.NamePrefix = "FHIR"
+	swagger = generate(t, g, testSchema)
+	content = swagger.Components.RequestBodies[patchRequestBodyName].Value.Content
+	if ref := content[JSONPatchContentType].Schema.Ref; ref != "#/components/schemas/FHIRJSONPatch" {
+		t.Errorf("JSON Patch body ignores the naming: %s", ref)
+	}
+	if patch := swagger.Components.Schemas["FHIRJSONPatch"]; patch == nil || patch.Value.Items.Ref != "#/components/schemas/FHIRJSONPatchOperation" {
+		t.Errorf("JSON Patch schema ignores the naming: %+v", patch)
+	}
+
+	g = New()
+	g.NameMapping = map[string]string{"HumanName": jsonPatchName}
+	if err := g.Do(strings.NewReader(testSchema), ioutil.Discard, JSON); err == nil {
+		t.Error("collision with the JSON Patch schema is not detected")
+	}
+}
+
+func TestWellKnown(t *testing.T) {
+	schema := strings.Replace(testSchema, `"Parameters": {`,
+		`"CapabilityStatement": {"properties": {"resourceType": {"const": "CapabilityStatement"}}, "required": ["resourceType"]},
+		"Parameters": {`, 1)
+
+	g := New()
+	swagger := generate(t, g, schema)
+	metadata := swagger.Paths["/metadata"]
+	if metadata == nil {
+		t.Fatal("path /metadata is not generated")
+	}
+	if param := metadata.Get.Parameters[0].Value; param.Name != "mode" || len(param.Schema.Value.Enum) != 3 {
+		t.Errorf("unexpected mode parameter: %+v", param)
+	}
+	if ref := metadata.Get.Responses["200"].Value.Content.Get("application/json").Schema.Ref; ref != "#/components/schemas/CapabilityStatement" {
+		t.Errorf("unexpected capabilities response: %s", ref)
+	}
+	if metadata.Get.Security == nil || len(*metadata.Get.Security) != 0 {
+		t.Error("capabilities interaction requires authentication")
+	}
+	if _, ok := swagger.Paths["/.well-known/udap"]; ok {
+		t.Error("UDAP metadata is generated")
+	}
+	if swagger.Paths["/.well-known/smart-configuration"] == nil {
+		t.Error("path /.well-known/smart-configuration is not generated")
+	}
+	smart := swagger.Components.Schemas[smartConfigurationName]
+	if smart == nil {
+		t.Fatalf("schema %s is not generated", smartConfigurationName)
+	}
+	if prop := smart.Value.Properties["token_endpoint"]; prop == nil || prop.Value.Format != "uri" {
+		t.Errorf("unexpected token_endpoint: %+v", prop)
+	}
+
+	g = New()
+	g.SMART = false
+	g.UDAP = true
+	g.NamePrefix = "FHIR"
+	swagger = generate(t, g, schema)
+	if _, ok := swagger.Paths["/.well-known/smart-configuration"]; ok {
+		t.Error("disabled SMART configuration is generated")
+	}
+	udap := swagger.Paths["/.well-known/udap"]
+	if udap == nil {
+		t.Fatal("path /.well-known/udap is not generated")
+	}
+	if ref := udap.Get.Responses["200"].Value.Content.Get("application/json").Schema.Ref; ref != "#/components/schemas/FHIR"+udapMetadataName {
+		t.Errorf("UDAP metadata ignores the naming: %s", ref)
+	}
+	if swagger.Components.Schemas["FHIR"+udapMetadataName] == nil {
+		t.Errorf("schema FHIR%s is not generated", udapMetadataName)
+	}
+
+	g = New()
+	g.Include = []string{"Patient"}
+	swagger = generate(t, g, schema)
+	if _, ok := swagger.Paths["/metadata"]; !ok {
+		t.Error("capabilities interaction depends on the selected resources")
+	}
+	if _, ok := swagger.Components.Schemas[capabilityStatementName]; !ok {
+		t.Error("CapabilityStatement of the capabilities interaction is pruned")
+	}
+	if _, ok := swagger.Paths["/"+capabilityStatementName]; ok {
+		t.Error("paths of the unselected CapabilityStatement are generated")
+	}
+}
+
+func TestBundles(t *testing.T) {
+	schema := strings.Replace(testSchema,
+		`"Bundle": {"properties": {"resourceType": {"const": "Bundle"}}, "required": ["resourceType"]},`,
+		`"Bundle": {"properties": {"resourceType": {"const": "Bundle"}, "type": {"enum": ["transaction", "batch", "searchset"]}, "entry": {"items": {"$ref": "#/definitions/Bundle_Entry"}, "type": "array"}}, "required": ["resourceType"]},
+		"Bundle_Entry": {"properties": {"resource": {"$ref": "#/definitions/Patient"}, "request": {"$ref": "#/definitions/Bundle_Request"}, "response": {"$ref": "#/definitions/Bundle_Response"}}},
+		"Bundle_Request": {"properties": {"method": {"enum": ["GET", "POST"]}, "url": {"$ref": "#/definitions/string"}}, "required": ["method", "url"]},
+		"Bundle_Response": {"properties": {"status": {"$ref": "#/definitions/string"}}, "required": ["status"]},`, 1)
+
+	g := New()
+	swagger := generate(t, g, schema)
+	root := swagger.Paths["/"]
+	if root.Put != nil {
+		t.Error("PUT / is generated")
+	}
+	body := root.Post.RequestBody.Value.Content.Get("application/json").Schema.Value
+	if len(body.OneOf) != 2 || body.Discriminator == nil || body.Discriminator.Mapping["batch"] != "#/components/schemas/BatchBundle" {
+		t.Errorf("unexpected request body: %+v", body)
+	}
+	resp := root.Post.Responses["200"].Value.Content.Get("application/json").Schema.Value
+	if len(resp.OneOf) != 2 || resp.OneOf[0].Ref != "#/components/schemas/TransactionResponseBundle" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	schemas := swagger.Components.Schemas
+	transaction := schemas["TransactionBundle"].Value
+	if typ := transaction.Properties["type"].Value; len(typ.Enum) != 1 || typ.Enum[0] != "transaction" {
+		t.Errorf("unexpected transaction type: %+v", typ)
+	}
+	if ref := transaction.Properties["entry"].Value.Items.Ref; ref != "#/components/schemas/"+bundleRequestEntryName {
+		t.Errorf("unexpected transaction entry: %s", ref)
+	}
+	if required := schemas[bundleRequestEntryName].Value.Required; !reflect.DeepEqual(required, []string{"request"}) {
+		t.Errorf("unexpected request entry required properties: %v", required)
+	}
+	if required := schemas[bundleResponseEntryName].Value.Required; !reflect.DeepEqual(required, []string{"response"}) {
+		t.Errorf("unexpected response entry required properties: %v", required)
+	}
+	if _, ok := swagger.Paths["/TransactionBundle"]; ok {
+		t.Error("path of the transaction Bundle is generated")
+	}
+
+	g = New()
+	if err := g.LoadCapabilityStatement(strings.NewReader(testCapabilityStatement)); err != nil {
+		t.Fatal(err)
+	}
+	swagger = generate(t, g, schema)
+	if ref := swagger.Paths["/"].Post.RequestBody.Value.Content.Get("application/json").Schema.Ref; ref != "#/components/schemas/TransactionBundle" {
+		t.Errorf("unexpected transaction request body: %s", ref)
+	}
+	if _, ok := swagger.Components.Schemas["BatchBundle"]; ok {
+		t.Error("not supported batch Bundle is generated")
+	}
+}
+
+func TestCompartments(t *testing.T) {
+	g := New()
+	err := g.LoadResources(strings.NewReader(`{
+		"resourceType": "Bundle",
+		"entry": [
+			{"resource": {
+				"resourceType": "CompartmentDefinition",
+				"url": "http://hl7.org/fhir/CompartmentDefinition/patient",
+				"code": "Patient",
+				"search": true,
+				"resource": [
+					{"code": "Patient", "param": ["link"]},
+					{"code": "Observation", "param": ["subject"]},
+					{"code": "Bundle"}
+				]
+			}},
+			{"resource": {"resourceType": "CompartmentDefinition", "code": "Encounter", "search": true, "resource": [{"code": "Patient", "param": ["encounter"]}]}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadResources(strings.NewReader(testSearchParameters)); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.CompartmentDefinitions) != 2 {
+		t.Fatalf("unexpected compartment definitions: %v", g.CompartmentDefinitions)
+	}
+	paths := generate(t, g, testSchema).Paths
+
+	member := paths["/Patient/{id}/Patient"]
+	if member == nil || member.Get == nil {
+		t.Fatal("compartment member path is not generated")
+	}
+	if !reflect.DeepEqual(member.Get.Tags, []string{"Patient"}) {
+		t.Errorf("unexpected tags: %v", member.Get.Tags)
+	}
+	refs := map[string]bool{}
+	for _, p := range member.Get.Parameters {
+		refs[p.Ref] = true
+	}
+	if !refs["#/components/parameters/Patient-birthdate"] {
+		t.Errorf("member search has no resource search parameters: %v", refs)
+	}
+	if paths["/Patient/{id}/*"] == nil {
+		t.Error("compartment path is not generated")
+	}
+	for _, path := range []string{"/Patient/{id}/Bundle", "/Patient/{id}/Observation", "/Encounter/{id}/Patient", "/Encounter/{id}/*"} {
+		if _, ok := paths[path]; ok {
+			t.Errorf("path %s is generated", path)
+		}
+	}
+}
+
+func TestFaithfulStructureDefinitions(t *testing.T) {
+	sds := strings.Replace(testStructureDefinitions, `"entry": [`, `"entry": [
+		{"resource": {
+			"resourceType": "StructureDefinition",
+			"url": "http://hl7.org/fhir/StructureDefinition/date",
+			"kind": "primitive-type",
+			"type": "date",
+			"snapshot": {"element": [
+				{"path": "date", "min": 0, "max": "*"},
+				{"path": "date.value", "min": 0, "max": "1", "type": [{
+					"extension": [{"url": "http://hl7.org/fhir/StructureDefinition/regex", "valueString": "[0-9]{4}(-[0-9]{2}(-[0-9]{2})?)?"}],
+					"code": "http://hl7.org/fhirpath/System.Date"
+				}]}
+			]}
+		}},`, 1)
+
+	g := New()
+	g.PrimitiveMapping = FaithfulMapping
+	if err := g.LoadResources(strings.NewReader(sds)); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := g.Do(nil, &out, JSON); err != nil {
+		t.Fatal(err)
+	}
+	var swagger openapi3.Swagger
+	if err := json.Unmarshal(out.Bytes(), &swagger); err != nil {
+		t.Fatal(err)
+	}
+	patient := swagger.Components.Schemas["Patient"].Value
+	if birthDate := patient.Properties["birthDate"]; birthDate.Ref != "" || birthDate.Value.Type != "string" || birthDate.Value.Pattern == "" {
+		t.Errorf("unexpected faithful date: %+v", birthDate)
+	}
+	if deceased := patient.Properties["deceasedBoolean"]; deceased.Ref != "" || deceased.Value.Type != "boolean" {
+		t.Errorf("unexpected faithful boolean: %+v", deceased)
+	}
+}
@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Extensions of the JSON Schema keywords that have no OpenAPI 3.0 equivalents.
+const (
+	patternPropertiesExt = "x-jsonschema-patternProperties"
+	dependenciesExt      = "x-jsonschema-dependencies"
+	additionalItemsExt   = "x-jsonschema-additionalItems"
+	mediaExt             = "x-jsonschema-media"
+	binaryEncodingExt    = "x-jsonschema-binaryEncoding"
+)
+
+// convertKeywords converts the JSON Schema keywords that are not mapped directly.
+// additionalProperties and not are converted into their OpenAPI equivalents, the other keywords are kept as extensions.
+// additionalProperties is not generated for the objects with the skipped primitive extension properties.
+func (g *Generator) convertKeywords(src *Type, dst *openapi3.Schema) {
+	if len(src.AdditionalProperties) > 0 && !g.hasSkippedProperties(src.Properties) {
+		var allowed bool
+		if err := json.Unmarshal(src.AdditionalProperties, &allowed); err == nil {
+			dst.AdditionalPropertiesAllowed = &allowed
+		} else {
+			var t Type
+			if err := json.Unmarshal(src.AdditionalProperties, &t); err == nil {
+				dst.AdditionalProperties = g.convertSchema(&t)
+			}
+		}
+	}
+	dst.Not = g.convertSchema(src.Not)
+
+	extensions := make(map[string]interface{})
+	if len(src.PatternProperties) > 0 {
+		extensions[patternPropertiesExt] = g.convertNamedSchemas(src.PatternProperties, false)
+	}
+	if len(src.Dependencies) > 0 {
+		extensions[dependenciesExt] = g.convertNamedSchemas(src.Dependencies, false)
+	}
+	if src.AdditionalItems != nil {
+		extensions[additionalItemsExt] = g.convertSchema(src.AdditionalItems)
+	}
+	if src.Media != nil {
+		extensions[mediaExt] = g.convertSchema(src.Media)
+	}
+	if src.BinaryEncoding != "" {
+		extensions[binaryEncodingExt] = src.BinaryEncoding
+	}
+	if len(extensions) == 0 {
+		return
+	}
+	for name, value := range src.Extras {
+		extensions[name] = value
+	}
+	dst.Extensions = extensions
+}
+
+// hasSkippedProperties checks that the underscore properties are skipped.
+// Such objects are not closed, otherwise the skipped properties would be rejected.
+func (g *Generator) hasSkippedProperties(props map[string]*Type) bool {
+	for name := range props {
+		if g.isSkipped(name, props) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportUnsupportedKeywords adds the warnings about the keywords of the definitions that can not be represented
+// in OpenAPI 3.0 and are kept as extensions or dropped. The definitions left open since their primitive extension
+// properties are skipped are reported if the verbose warnings are enabled, it is the case of most FHIR definitions.
+func (g *Generator) reportUnsupportedKeywords() {
+	names := make([]string, 0, len(g.Schema.Definitions))
+	for name := range g.Schema.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := g.Schema.Definitions[name]
+		g.reportKeywords(name, def)
+		if g.Verbose && len(def.AdditionalProperties) > 0 && g.hasSkippedProperties(def.Properties) {
+			g.warnf("%s: additionalProperties is not generated since the primitive extension properties are skipped", name)
+		}
+	}
+}
+
+func (g *Generator) reportKeywords(path string, t *Type) {
+	if t == nil {
+		return
+	}
+	keywords := []struct {
+		name    string
+		present bool
+	}{
+		{"patternProperties", len(t.PatternProperties) > 0},
+		{"dependencies", len(t.Dependencies) > 0},
+		{"additionalItems", t.AdditionalItems != nil},
+		{"media", t.Media != nil},
+		{"binaryEncoding", t.BinaryEncoding != ""},
+	}
+	for _, k := range keywords {
+		if k.present {
+			g.warnf("%s: %s can not be represented in OpenAPI 3.0 and is kept as an extension", path, k.name)
+		}
+	}
+	if len(t.Definitions) > 0 {
+		g.warnf("%s: nested definitions are not supported and are dropped", path)
+	}
+
+	props := make([]string, 0, len(t.Properties))
+	for name := range t.Properties {
+		props = append(props, name)
+	}
+	sort.Strings(props)
+	for _, name := range props {
+		g.reportKeywords(path+"."+name, t.Properties[name])
+	}
+	g.reportKeywords(path+"[]", t.Items)
+	g.reportKeywords(path+".not", t.Not)
+	for i, list := range [][]*Type{t.AllOf, t.AnyOf, t.OneOf} {
+		for j, sub := range list {
+			g.reportKeywords(fmt.Sprintf("%s.%s[%d]", path, []string{"allOf", "anyOf", "oneOf"}[i], j), sub)
+		}
+	}
+}
+
+// warnf adds the warning about the generation.
+func (g *Generator) warnf(format string, args ...interface{}) {
+	g.Warnings = append(g.Warnings, fmt.Sprintf(format, args...))
+}
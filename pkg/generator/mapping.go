@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// PrimitiveMapping is the mode of the mapping of the FHIR primitive types to the OpenAPI types.
+type PrimitiveMapping string
+
+// Primitive mapping modes.
+const (
+	// LossyMapping maps the primitive types to the OpenAPI types and formats friendly for code generation,
+	// e.g. date to the string of the date format rejecting partial dates.
+	LossyMapping PrimitiveMapping = "lossy"
+	// FaithfulMapping maps the primitive types to the types accepting all FHIR values and keeps the FHIR regex patterns.
+	FaithfulMapping PrimitiveMapping = "faithful"
+)
+
+const decimalTypeName = "decimal"
+
+var (
+	zero = 0.0
+	one  = 1.0
+)
+
+// faithfulTypes are the faithful types of the primitive types that are not patterned strings.
+var faithfulTypes = map[string]*Type{
+	"boolean":      {Type: "boolean"},
+	"integer":      {Type: "integer"},
+	"positiveInt":  {Type: "integer", Minimum: &one},
+	"unsignedInt":  {Type: "integer", Minimum: &zero},
+	"decimal":      {Type: "number"},
+	"base64Binary": {Type: "string", Format: "byte"},
+	"instant":      {Type: "string", Format: "date-time"},
+	"xhtml":        {Type: "string"},
+}
+
+// ParsePrimitiveMapping parses the primitive mapping mode.
+func ParsePrimitiveMapping(s string) (PrimitiveMapping, error) {
+	switch mapping := PrimitiveMapping(strings.ToLower(s)); mapping {
+	case LossyMapping, FaithfulMapping:
+		return mapping, nil
+	}
+	return "", fmt.Errorf("unknown primitive mapping «%s»", s)
+}
+
+// initTypeMapper initializes the mapper of the primitive types according to the mapping mode and the FHIR version.
+// The user-supplied type mapping overrides the mapping of the mode.
+func (g *Generator) initTypeMapper() {
+	m := NewLossyTypeMapper()
+	if g.Version == R5 {
+		// integer64 is represented as a JSON string.
+		m.Add("integer64", &Type{Type: "string"})
+	}
+	if g.PrimitiveMapping == FaithfulMapping {
+		m = g.faithfulMapper()
+	}
+	if g.DecimalAsString {
+		m.Add(decimalTypeName, &Type{Type: "string", Pattern: "^" + numberPattern + "$"})
+	}
+	for name, t := range g.TypeMapping {
+		m.Add(name, t)
+	}
+	g.mapper = m
+}
+
+// LoadTypeMapping reads the YAML or JSON mapping of the primitive type names to the OpenAPI types
+// that overrides or extends the mapping of the primitive types, e.g.
+//
+//	instant:
+//	  type: string
+//	  format: date-time
+//	  x-go-type: time.Time
+//
+// The type, format, pattern, minimum and maximum keywords and the extensions are supported.
+func (g *Generator) LoadTypeMapping(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		return fmt.Errorf("decoding type mapping: %w", err)
+	}
+	var mapping map[string]json.RawMessage
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return fmt.Errorf("decoding type mapping: %w", err)
+	}
+	for name, raw := range mapping {
+		t, err := decodeMappedType(raw)
+		if err != nil {
+			return fmt.Errorf("decoding type mapping of «%s»: %w", name, err)
+		}
+		g.TypeMapping[name] = t
+	}
+	return nil
+}
+
+// decodeMappedType decodes the type keeping its extensions.
+func decodeMappedType(data json.RawMessage) (*Type, error) {
+	var t Type
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	var keywords map[string]interface{}
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, err
+	}
+	for name, value := range keywords {
+		if strings.HasPrefix(name, "x-") {
+			t.Extras = setExtra(t.Extras, name, value)
+		}
+	}
+	return &t, nil
+}
+
+// faithfulMapper returns the mapper of the defined primitive types keeping the patterns of their definitions.
+// Dates are mapped to the patterned strings accepting partial dates.
+func (g *Generator) faithfulMapper() *TypeMapper {
+	m := NewTypeMapper(definitionsPrefix)
+	for name, def := range g.Schema.Definitions {
+		if !isPrimitiveType(name) {
+			continue
+		}
+		if t, ok := faithfulTypes[name]; ok {
+			m.Add(name, t)
+		} else {
+			m.Add(name, &Type{Type: "string", Pattern: def.Pattern})
+		}
+	}
+	return m
+}
+
+// typeMapper returns the mapper of the primitive types.
+func (g *Generator) typeMapper() *TypeMapper {
+	if g.mapper == nil {
+		g.initTypeMapper()
+	}
+	return g.mapper
+}
@@ -0,0 +1,71 @@
+package generator
+
+import "strings"
+
+// Element metadata annotations.
+const (
+	pathExt       = "x-fhir-path"
+	minExt        = "x-fhir-min"
+	maxExt        = "x-fhir-max"
+	isSummaryExt  = "x-fhir-is-summary"
+	isModifierExt = "x-fhir-is-modifier"
+)
+
+// annotateElements annotates the definitions and their properties with the metadata of the element definitions
+// they are built from.
+func (g *Generator) annotateElements(defs Definitions) {
+	if !g.ElementMetadata {
+		return
+	}
+	for _, def := range defs {
+		if def.Element != nil {
+			def.Extras = setExtra(def.Extras, pathExt, def.Element.Path)
+		}
+		for name, prop := range def.Properties {
+			if prop.Element != nil && !strings.HasPrefix(name, extensionsPrefix) {
+				annotateElement(prop, prop.Element)
+			}
+		}
+	}
+}
+
+// annotateElement annotates the property with the metadata of the element.
+func annotateElement(prop *Type, e *ElementDefinition) {
+	prop.Extras = setExtra(prop.Extras, pathExt, e.Path)
+	prop.Extras[minExt] = e.Min
+	if e.Max != "" {
+		prop.Extras[maxExt] = e.Max
+	}
+	if e.IsSummary {
+		prop.Extras[isSummaryExt] = true
+	}
+	if e.IsModifier {
+		prop.Extras[isModifierExt] = true
+	}
+	if e.MustSupport {
+		prop.Extras[mustSupportExt] = true
+	}
+	if b := e.Binding; b != nil {
+		binding := map[string]interface{}{"strength": b.Strength}
+		if b.ValueSet != "" {
+			binding["valueSet"] = b.ValueSet
+		}
+		if b.Description != "" {
+			binding["description"] = b.Description
+		}
+		prop.Extras[bindingExt] = binding
+	}
+}
+
+// annotateProfileElement updates the metadata annotations of the property constrained by the profile element.
+func (g *Generator) annotateProfileElement(prop *Type, e *ElementDefinition) {
+	if !g.ElementMetadata {
+		return
+	}
+	if e.Min > 0 {
+		prop.Extras = setExtra(prop.Extras, minExt, e.Min)
+	}
+	if e.Max != "" {
+		prop.Extras = setExtra(prop.Extras, maxExt, e.Max)
+	}
+}
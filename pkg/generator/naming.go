@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+)
+
+// NamingStrategy is the strategy of the naming of the components built from the definitions, e.g. Patient_Contact.
+type NamingStrategy string
+
+// Naming strategies.
+const (
+	// NamingKeep keeps the definition names.
+	NamingKeep NamingStrategy = "keep"
+	// NamingPascalCase joins the underscore separated parts of the definition names in PascalCase,
+	// e.g. Patient_Contact to PatientContact.
+	NamingPascalCase NamingStrategy = "pascal"
+)
+
+const namePartSeparator = "_"
+
+// ParseNamingStrategy parses the naming strategy.
+func ParseNamingStrategy(s string) (NamingStrategy, error) {
+	switch strategy := NamingStrategy(strings.ToLower(s)); strategy {
+	case NamingKeep, NamingPascalCase:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown naming strategy «%s»", s)
+}
+
+// componentName returns the name of the component of the definition.
+// The name mapping overrides the naming strategy, the prefix and the suffix.
+func (g *Generator) componentName(name string) string {
+	if mapped, ok := g.NameMapping[name]; ok {
+		return mapped
+	}
+	if g.Naming == NamingPascalCase {
+		parts := strings.Split(name, namePartSeparator)
+		for i, part := range parts {
+			parts[i] = upperFirst(part)
+		}
+		name = strings.Join(parts, "")
+	}
+	return g.NamePrefix + name + g.NameSuffix
+}
+
+// componentRef returns the reference to the component of the definition reference.
+func (g *Generator) componentRef(ref string) string {
+	if !strings.HasPrefix(ref, definitionsPrefix) {
+		return ref
+	}
+	return componentSchemasPrefix + g.componentName(strings.TrimPrefix(ref, definitionsPrefix))
+}
+
+// schemaRef returns the reference to the component schema of the definition.
+func (g *Generator) schemaRef(name string) *openapi3.SchemaRef {
+	return NewSchemaRef(g.componentName(name))
+}
+
+// responseName returns the name of the response component of the entity.
+func (g *Generator) responseName(entity string) string {
+	return g.componentName(entity) + ResposePostfix
+}
+
+// checkComponentNames fails if the names of the components of the different definitions collide.
+func (g *Generator) checkComponentNames() error {
+	names := make([]string, 0, len(g.Schema.Definitions))
+	for name := range g.Schema.Definitions {
+		if name != "" && !g.isSkipped(name, g.Schema.Definitions) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	components := make(map[string]string, len(names))
+	for _, name := range names {
+		component := g.componentName(name)
+		if other, ok := components[component]; ok {
+			return fmt.Errorf("component name «%s» of «%s» collides with «%s»", component, name, other)
+		}
+		components[component] = name
+	}
+	return nil
+}
+
+// addComponentSchema adds the schema that is not built from a definition under the component name of the name.
+// It fails if the component name collides with an existing component.
+func (g *Generator) addComponentSchema(name string, schema *openapi3.Schema) error {
+	component := g.componentName(name)
+	if _, ok := g.Swagger.Components.Schemas[component]; ok {
+		return fmt.Errorf("component name «%s» of «%s» collides with an existing component", component, name)
+	}
+	g.Swagger.Components.Schemas[component] = openapi3.NewSchemaRef("", schema)
+	return nil
+}
+
+// LoadNameMapping reads the YAML or JSON mapping of the definition names to the component names, e.g.
+//
+//	Patient_Contact: PatientContact
+//	Bundle_Entry: BundleEntry
+func (g *Generator) LoadNameMapping(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if data, err = yaml.YAMLToJSON(data); err != nil {
+		return fmt.Errorf("decoding name mapping: %w", err)
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return fmt.Errorf("decoding name mapping: %w", err)
+	}
+	for name, component := range mapping {
+		g.NameMapping[name] = component
+	}
+	return nil
+}
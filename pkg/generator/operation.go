@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gotidy/ptr"
+)
+
+const (
+	operationPrefix  = "$"
+	operationsTag    = "operations"
+	returnParameter  = "return"
+	parametersName   = "Parameters"
+	operationCodeExt = "x-fhir-operation"
+)
+
+// AddOperationDefinition registers the operation definition.
+// A definition with the same canonical URL already registered is replaced.
+func (g *Generator) AddOperationDefinition(op *OperationDefinition) {
+	key := op.URL
+	if key == "" {
+		key = op.Name
+	}
+	g.OperationDefinitions[key] = op
+}
+
+// operationDefinitions returns the operation definitions sorted by URL.
+func (g *Generator) operationDefinitions() []*OperationDefinition {
+	ops := make([]*OperationDefinition, 0, len(g.OperationDefinitions))
+	for _, op := range g.OperationDefinitions {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].URL < ops[j].URL })
+	return ops
+}
+
+// createOperationPathes creates the system, type and instance level paths of the operations.
+// If the capability statement is loaded only the declared operations are created.
+func (g *Generator) createOperationPathes() {
+	if rest := g.serverRest(); rest != nil {
+		g.createDeclaredOperationPathes(rest)
+		return
+	}
+	for _, op := range g.operationDefinitions() {
+		if op.System {
+			g.Swagger.Paths["/"+operationPrefix+op.Code] = g.operationPathItem(op, "", false)
+		}
+		if !op.Type && !op.Instance {
+			continue
+		}
+		for _, entity := range g.operationResources(op) {
+			if op.Type {
+				g.Swagger.Paths["/"+entity+"/"+operationPrefix+op.Code] = g.operationPathItem(op, entity, false)
+			}
+			if op.Instance {
+				g.Swagger.Paths["/"+entity+"/{id}/"+operationPrefix+op.Code] = g.operationPathItem(op, entity, true)
+			}
+		}
+	}
+}
+
+// operationResources returns the selected resources the operation is defined for.
+// The abstract resources are expanded to all resources.
+func (g *Generator) operationResources(op *OperationDefinition) []string {
+	var entities []string
+	for _, res := range op.Resource {
+		switch {
+		case res == BaseResource || res == BaseDomainResource:
+			for _, name := range g.resourceNames() {
+				if (res == BaseResource || g.isDomainResource(name)) && g.isSelected(name) {
+					entities = append(entities, name)
+				}
+			}
+		case g.isResource(res) && g.isSelected(res):
+			entities = append(entities, res)
+		}
+	}
+	return entities
+}
+
+// operationPathItem returns the path item of the operation invoked on the entity.
+// POST accepts the Parameters resource, GET is available for operations that do not affect the state
+// and have only primitive input parameters.
+func (g *Generator) operationPathItem(op *OperationDefinition, entity string, instance bool) *openapi3.PathItem {
+	tags := []string{operationsTag}
+	if entity != "" {
+		tags = []string{entity}
+	}
+	description := op.Description
+	if description == "" {
+		description = op.Title
+	}
+	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
+	responses := openapi3.Responses{
+		"200": &openapi3.ResponseRef{Value: &openapi3.Response{
+			Description: ptr.String("OK"),
+			Content:     openapi3.NewContentWithJSONSchemaRef(g.operationOutput(op)),
+		}},
+		"400": respErr,
+		"401": respErr,
+		"403": respErr,
+		"404": respErr,
+		"422": respErr,
+	}
+	extensions := map[string]interface{}{operationCodeExt: operationPrefix + op.Code}
+
+	item := &openapi3.PathItem{
+		Post: &openapi3.Operation{
+			ExtensionProps: openapi3.ExtensionProps{Extensions: extensions},
+			Description:    description,
+			Tags:           tags,
+			RequestBody:    NewRequestBodyWithContent(openapi3.NewContentWithJSONSchemaRef(g.schemaRef(parametersName)), false),
+			Responses:      responses,
+		},
+	}
+	if params, ok := g.operationQueryParameters(op); ok && !op.AffectsState {
+		item.Get = &openapi3.Operation{
+			ExtensionProps: openapi3.ExtensionProps{Extensions: extensions},
+			Description:    description,
+			Tags:           tags,
+			Parameters:     params,
+			Responses:      responses,
+		}
+	}
+	if instance {
+		item.Parameters = openapi3.Parameters{NewParameterWithSchema(InPath, "id", true, NewSchemaString())}
+	}
+	return item
+}
+
+// operationQueryParameters returns the query parameters of the operation input parameters.
+// It fails if any input parameter is not primitive.
+func (g *Generator) operationQueryParameters(op *OperationDefinition) (openapi3.Parameters, bool) {
+	var params openapi3.Parameters
+	for _, p := range op.Parameter {
+		if p.Use != OperationParameterIn {
+			continue
+		}
+		if len(p.Part) > 0 || !isPrimitiveType(p.Type) {
+			return nil, false
+		}
+		schema := g.convertSchema(&Type{Ref: definitionsPrefix + p.Type})
+		if p.Max != "1" {
+			schema = &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "array", Items: schema}}
+		}
+		param := NewParameterWithSchema(InQuery, p.Name, p.Min > 0, schema)
+		param.Value.Description = p.Documentation
+		params = append(params, param)
+	}
+	return params, true
+}
+
+// operationOutput returns the schema of the operation output.
+// It is the resource if the operation returns the single resource, else the Parameters resource.
+func (g *Generator) operationOutput(op *OperationDefinition) *openapi3.SchemaRef {
+	var out []OperationDefinitionParameter
+	for _, p := range op.Parameter {
+		if p.Use == OperationParameterOut {
+			out = append(out, p)
+		}
+	}
+	if len(out) != 1 || out[0].Name != returnParameter || out[0].Max != "1" {
+		return g.schemaRef(parametersName)
+	}
+	switch typ := out[0].Type; {
+	case typ == resourceTypeName:
+		return g.schemaRef(resourceListName)
+	case g.isResource(typ):
+		return g.schemaRef(typ)
+	}
+	return g.schemaRef(parametersName)
+}
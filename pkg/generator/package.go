@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	packageDir      = "package"
+	packageManifest = "package.json"
+	packageIndex    = ".index.json"
+)
+
+// DefaultPackageCache returns the default location of the FHIR package cache.
+func DefaultPackageCache() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".fhir", "packages")
+}
+
+// LoadPackage loads the conformance resources of the FHIR NPM package.
+// The package is a .tgz file, an extracted package directory or a package reference "id#version"
+// located in the package cache. If the version is omitted the latest cached version is used.
+func (g *Generator) LoadPackage(pkg string) error {
+	if strings.HasSuffix(pkg, ".tgz") || strings.HasSuffix(pkg, ".tar.gz") {
+		return g.loadPackageArchive(pkg)
+	}
+	if info, err := os.Stat(pkg); err == nil && info.IsDir() {
+		return g.loadPackageDir(pkg)
+	}
+	dir, err := g.cachedPackageDir(pkg)
+	if err != nil {
+		return err
+	}
+	return g.loadPackageDir(dir)
+}
+
+// loadPackageDir loads the resources of the extracted package.
+// The directory is either the package root or the "package" folder itself.
+func (g *Generator) loadPackageDir(dir string) error {
+	if info, err := os.Stat(filepath.Join(dir, packageDir)); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, packageDir)
+	}
+	return walkResourceDir(dir, g.loadResourceFile)
+}
+
+// walkResourceDir calls the function for the resource files of the directory.
+func walkResourceDir(dir string, fn func(name string) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() || !isPackageResource(file.Name()) {
+			continue
+		}
+		if err := fn(filepath.Join(dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Generator) loadResourceFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := g.LoadResources(f); err != nil {
+		return fmt.Errorf("loading «%s»: %w", name, err)
+	}
+	return nil
+}
+
+// loadPackageArchive loads the resources of the package tarball.
+func (g *Generator) loadPackageArchive(name string) error {
+	return walkPackageArchive(name, packageDir, func(_ string, r io.Reader) error {
+		return g.LoadResources(r)
+	})
+}
+
+// walkPackageArchive calls the function for the resource files of the directory of the package tarball.
+func walkPackageArchive(name, dir string, fn func(file string, r io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading package «%s»: %w", name, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading package «%s»: %w", name, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		file := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if path.Dir(file) != dir || !isPackageResource(path.Base(file)) {
+			continue
+		}
+		if err := fn(file, tr); err != nil {
+			return fmt.Errorf("loading «%s» from package «%s»: %w", file, name, err)
+		}
+	}
+}
+
+// isPackageResource checks that the package file contains a resource.
+func isPackageResource(name string) bool {
+	return strings.HasSuffix(name, ".json") && name != packageManifest && name != packageIndex
+}
+
+// cachedPackageDir returns the directory of the package in the package cache.
+func (g *Generator) cachedPackageDir(ref string) (string, error) {
+	if g.PackageCache == "" {
+		return "", fmt.Errorf("package «%s»: package cache is not set", ref)
+	}
+	id, version := parsePackageReference(ref)
+	if version == "" || version == "latest" {
+		versions, err := g.cachedPackageVersions(id)
+		if err != nil {
+			return "", err
+		}
+		if len(versions) == 0 {
+			return "", fmt.Errorf("package «%s» is not found in the cache «%s»", ref, g.PackageCache)
+		}
+		version = versions[len(versions)-1]
+	}
+	dir := filepath.Join(g.PackageCache, id+"#"+version)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("package «%s» is not found in the cache «%s»", ref, g.PackageCache)
+	}
+	return dir, nil
+}
+
+// cachedPackageVersions returns the sorted versions of the package in the package cache.
+func (g *Generator) cachedPackageVersions(id string) ([]string, error) {
+	files, err := ioutil.ReadDir(g.PackageCache)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, file := range files {
+		if file.IsDir() && strings.HasPrefix(file.Name(), id+"#") {
+			versions = append(versions, strings.TrimPrefix(file.Name(), id+"#"))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) })
+	return versions, nil
+}
+
+// parsePackageReference splits the package reference "id#version" or "id@version" into the id and the version.
+func parsePackageReference(ref string) (id, version string) {
+	if i := strings.LastIndexAny(ref, "#@"); i > 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// versionLess compares the dot separated versions, numeric parts are compared as numbers.
+func versionLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gotidy/ptr"
+)
+
+// Patch content types.
+const (
+	JSONPatchContentType  = "application/json-patch+json"
+	FHIRContentType       = "application/fhir+json"
+	MergePatchContentType = "application/merge-patch+json"
+)
+
+const (
+	patchRequestBodyName   = "Patch"
+	jsonPatchName          = "JSONPatch"
+	jsonPatchOperationName = "JSONPatchOperation"
+)
+
+// jsonPatchOperations are the operations of JSON Patch (RFC 6902).
+var jsonPatchOperations = []string{"add", "remove", "replace", "move", "copy", "test"}
+
+// patchRequestBody returns the reference to the request body of the patch interaction, the body is added on first use.
+// The body is JSON Patch, FHIRPath Patch as the Parameters resource and JSON Merge Patch if it is enabled.
+func (g *Generator) patchRequestBody() *openapi3.RequestBodyRef {
+	components := &g.Swagger.Components
+	if _, ok := components.RequestBodies[patchRequestBodyName]; ok {
+		return NewRequestBodyRef(patchRequestBodyName)
+	}
+
+	content := openapi3.Content{
+		JSONPatchContentType: openapi3.NewMediaType().WithSchemaRef(g.schemaRef(jsonPatchName)),
+	}
+	if _, ok := g.Schema.Definitions[parametersName]; ok {
+		content[FHIRContentType] = openapi3.NewMediaType().WithSchemaRef(g.schemaRef(parametersName))
+	}
+	if g.MergePatch {
+		content[MergePatchContentType] = openapi3.NewMediaType().WithSchema(&openapi3.Schema{
+			Type:                        "object",
+			Description:                 "JSON Merge Patch (RFC 7396) of the resource.",
+			AdditionalPropertiesAllowed: ptr.Bool(true),
+		})
+	}
+	if components.RequestBodies == nil {
+		components.RequestBodies = make(openapi3.RequestBodies)
+	}
+	components.RequestBodies[patchRequestBodyName] = &openapi3.RequestBodyRef{Value: &openapi3.RequestBody{
+		Description: "The patch of the resource.",
+		Required:    true,
+		Content:     content,
+	}}
+	return NewRequestBodyRef(patchRequestBodyName)
+}
+
+// addPatchSchemas adds the schemas of JSON Patch if the request body of the patch interaction is used.
+func (g *Generator) addPatchSchemas() error {
+	if _, ok := g.Swagger.Components.RequestBodies[patchRequestBodyName]; !ok {
+		return nil
+	}
+	err := g.addComponentSchema(jsonPatchOperationName, &openapi3.Schema{
+		Type:        "object",
+		Description: "JSON Patch operation (RFC 6902).",
+		Properties: openapi3.Schemas{
+			"op":    NewSchemaEnum(jsonPatchOperations...),
+			"path":  NewSchemaString(),
+			"from":  NewSchemaString(),
+			"value": openapi3.NewSchemaRef("", &openapi3.Schema{}),
+		},
+		Required: []string{"op", "path"},
+	})
+	if err != nil {
+		return err
+	}
+	return g.addComponentSchema(jsonPatchName, &openapi3.Schema{
+		Type:        "array",
+		Description: "JSON Patch (RFC 6902).",
+		Items:       g.schemaRef(jsonPatchOperationName),
+	})
+}
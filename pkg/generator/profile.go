@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Profile annotations.
+const (
+	mustSupportExt = "x-fhir-must-support"
+	fixedExt       = "x-fhir-fixed"
+	patternExt     = "x-fhir-pattern"
+	profileExt     = "x-fhir-profile"
+	allProfiles    = "*"
+)
+
+// addProfileDefinitions adds the definitions of the selected profiles to the schema definitions.
+// The profile definition is the definition of the base resource or type constrained by the profile differential.
+func (g *Generator) addProfileDefinitions() error {
+	for _, sd := range g.selectedProfiles() {
+		def, err := g.profileDefinition(sd)
+		if err != nil {
+			return err
+		}
+		name := profileComponentName(sd.Name)
+		if name == sd.Type {
+			name += "Profile"
+		}
+		def.Extras = setExtra(def.Extras, profileExt, sd.URL)
+		g.Schema.Definitions[name] = def
+		g.profiles[name] = sd
+	}
+	return nil
+}
+
+// selectedProfiles returns the sorted by URL loaded profiles that are selected by URLs or names.
+// Extension definitions are not treated as profiles.
+func (g *Generator) selectedProfiles() []*StructureDefinition {
+	if len(g.Profiles) == 0 {
+		return nil
+	}
+	selected := make(map[string]bool, len(g.Profiles))
+	for _, p := range g.Profiles {
+		selected[p] = true
+	}
+
+	var sds []*StructureDefinition
+	for _, sd := range g.StructureDefinitions {
+		if sd.Derivation != DerivationConstraint || sd.Type == "Extension" || sd.Differential == nil {
+			continue
+		}
+		if sd.Kind != KindResource && sd.Kind != KindComplexType {
+			continue
+		}
+		if selected[allProfiles] || selected[sd.URL] || selected[sd.Name] {
+			sds = append(sds, sd)
+		}
+	}
+	sort.Slice(sds, func(i, j int) bool { return sds[i].URL < sds[j].URL })
+	return sds
+}
+
+// profileDefinition returns the definition of the base structure constrained by the profile.
+// Profiles derived from other profiles are applied in turn.
+func (g *Generator) profileDefinition(sd *StructureDefinition) (*Type, error) {
+	var def *Type
+	if base, ok := g.StructureDefinitions[canonicalURL(sd.BaseDefinition)]; ok && base.Derivation == DerivationConstraint {
+		var err error
+		if def, err = g.profileDefinition(base); err != nil {
+			return nil, err
+		}
+	} else if base, ok := g.Schema.Definitions[sd.Type]; ok {
+		def = base.clone()
+	} else {
+		return nil, fmt.Errorf("profile «%s»: definition of the base type «%s» is not found", sd.URL, sd.Type)
+	}
+
+	if sd.Description != "" {
+		def.Description = sd.Description
+	}
+	for _, e := range sd.Differential.Element {
+		g.applyElement(def, e)
+	}
+	return def, nil
+}
+
+// applyElement applies the constraints of the differential element to the definition.
+// Nested definitions are inlined to constrain them without affecting the other usages.
+// Slices are not supported and are skipped.
+func (g *Generator) applyElement(def *Type, e *ElementDefinition) {
+	if e.SliceName != "" || strings.Contains(e.ID, ":") {
+		return
+	}
+	segments := strings.Split(e.Path, ".")[1:]
+	if len(segments) == 0 {
+		return
+	}
+
+	owner := def
+	for _, segment := range segments[:len(segments)-1] {
+		prop, ok := owner.Properties[segment]
+		if !ok {
+			return
+		}
+		if owner = g.inlineDefinition(prop); owner == nil {
+			return
+		}
+	}
+
+	names := g.elementProperties(owner, segments[len(segments)-1], e.Type)
+	for _, name := range names {
+		prop := owner.Properties[name]
+		if e.Max == "0" {
+			delete(owner.Properties, name)
+			delete(owner.Properties, extensionsPrefix+name)
+			owner.Required = removeString(owner.Required, name)
+			continue
+		}
+		if e.Min > 0 && len(names) == 1 {
+			owner.Required = appendUnique(owner.Required, name)
+		}
+
+		value := prop
+		if prop.Type == "array" && prop.Items != nil {
+			if uint64(e.Min) > prop.MinItems {
+				prop.MinItems = uint64(e.Min)
+			}
+			if max, err := strconv.ParseUint(e.Max, 10, 64); err == nil {
+				prop.MaxItems = &max
+			}
+			value = prop.Items
+		}
+		if e.Binding != nil {
+			g.applyBinding(prop, e.Binding)
+		}
+		g.applyReferenceTargets(g.Schema.Definitions, prop, e.Type)
+		g.annotateProfileElement(prop, e)
+		if isScalar(e.Fixed) {
+			value.Enum = []interface{}{e.Fixed}
+		} else if e.Fixed != nil {
+			value.Extras = setExtra(value.Extras, fixedExt, e.Fixed)
+		}
+		if isScalar(e.Pattern) {
+			value.Enum = []interface{}{e.Pattern}
+		} else if e.Pattern != nil {
+			value.Extras = setExtra(value.Extras, patternExt, e.Pattern)
+		}
+		if e.MustSupport {
+			prop.Extras = setExtra(prop.Extras, mustSupportExt, true)
+		}
+	}
+}
+
+// elementProperties returns the names of the properties representing the element.
+// Choice element variants whose types are not allowed by the element types are removed.
+func (g *Generator) elementProperties(owner *Type, name string, types []ElementDefinitionType) []string {
+	if !strings.HasSuffix(name, choiceSuffix) {
+		if _, ok := owner.Properties[name]; ok {
+			return []string{name}
+		}
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(types))
+	for _, typ := range types {
+		allowed[upperFirst(elementTypeCode(typ))] = true
+	}
+	prefix := strings.TrimSuffix(name, choiceSuffix)
+	var names []string
+	for prop := range owner.Properties {
+		typ := strings.TrimPrefix(prop, prefix)
+		if !strings.HasPrefix(prop, prefix) || typ == "" || !unicode.IsUpper([]rune(typ)[0]) || !g.isDefinedType(typ) {
+			continue
+		}
+		if len(allowed) > 0 && !allowed[typ] {
+			delete(owner.Properties, prop)
+			delete(owner.Properties, extensionsPrefix+prop)
+			continue
+		}
+		names = append(names, prop)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isDefinedType checks that the type with the capitalized name is defined.
+func (g *Generator) isDefinedType(name string) bool {
+	if _, ok := g.Schema.Definitions[name]; ok {
+		return true
+	}
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	_, ok := g.Schema.Definitions[string(r)]
+	return ok
+}
+
+// inlineDefinition replaces the reference of the property or its items with the copy of the referenced definition
+// and returns the object definition.
+func (g *Generator) inlineDefinition(prop *Type) *Type {
+	t := prop
+	if t.Type == "array" && t.Items != nil {
+		t = t.Items
+	}
+	if t.Ref == "" {
+		return t
+	}
+	ref, ok := g.Schema.Definitions[strings.TrimPrefix(t.Ref, definitionsPrefix)]
+	if !ok {
+		return nil
+	}
+	description, extras := t.Description, t.Extras
+	*t = *ref.clone()
+	t.Description, t.Extras = description, extras
+	return t
+}
+
+// profileSchema returns the schema of the request and response bodies of the entity.
+// If the profile bodies are enabled and the entity is constrained by profiles, the schema refers to them.
+func (g *Generator) profileSchema(entity string) *openapi3.SchemaRef {
+	if !g.ProfileBodies {
+		return g.schemaRef(entity)
+	}
+	var names []string
+	for name, sd := range g.profiles {
+		if sd.Type == entity && sd.Kind == KindResource {
+			names = append(names, name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return g.schemaRef(entity)
+	case 1:
+		return g.schemaRef(names[0])
+	}
+	sort.Strings(names)
+	schema := &openapi3.Schema{}
+	for _, name := range names {
+		schema.AnyOf = append(schema.AnyOf, g.schemaRef(name))
+	}
+	return openapi3.NewSchemaRef("", schema)
+}
+
+// clone returns the deep copy of the type except the referenced element definitions.
+func (t *Type) clone() *Type {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Items = t.Items.clone()
+	c.Not = t.Not.clone()
+	c.AllOf = cloneTypes(t.AllOf)
+	c.AnyOf = cloneTypes(t.AnyOf)
+	c.OneOf = cloneTypes(t.OneOf)
+	c.Properties = cloneNamedTypes(t.Properties)
+	c.PatternProperties = cloneNamedTypes(t.PatternProperties)
+	c.Required = append([]string(nil), t.Required...)
+	c.Enum = append([]interface{}(nil), t.Enum...)
+	if t.Extras != nil {
+		c.Extras = make(map[string]interface{}, len(t.Extras))
+		for k, v := range t.Extras {
+			c.Extras[k] = v
+		}
+	}
+	return &c
+}
+
+func cloneTypes(src []*Type) []*Type {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*Type, len(src))
+	for i, t := range src {
+		dst[i] = t.clone()
+	}
+	return dst
+}
+
+func cloneNamedTypes(src map[string]*Type) map[string]*Type {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]*Type, len(src))
+	for name, t := range src {
+		dst[name] = t.clone()
+	}
+	return dst
+}
+
+// profileComponentName converts the FHIR name of the profile into the component name, e.g. observation-vitalsigns to ObservationVitalsigns.
+func profileComponentName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = upperFirst(w)
+	}
+	return strings.Join(words, "")
+}
+
+func setExtra(extras map[string]interface{}, name string, value interface{}) map[string]interface{} {
+	if extras == nil {
+		extras = make(map[string]interface{})
+	}
+	extras[name] = value
+	return extras
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, float64, bool:
+		return true
+	}
+	return false
+}
+
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+func removeString(ss []string, s string) []string {
+	dst := ss[:0]
+	for _, v := range ss {
+		if v != s {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
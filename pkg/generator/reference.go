@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// referenceTargetsExt is the extension with the resource types the reference may refer to.
+	referenceTargetsExt = "x-fhir-reference-targets"
+	referenceTypeName   = "Reference"
+	referenceProperty   = "reference"
+)
+
+// applyReferences annotates the reference properties of the definitions with the target resource types.
+func (g *Generator) applyReferences(defs Definitions) {
+	for _, def := range defs {
+		for _, prop := range def.Properties {
+			if prop.Element != nil {
+				g.applyReferenceTargets(defs, prop, prop.Element.Type)
+			}
+		}
+	}
+}
+
+// applyReferenceTargets annotates the reference property or its items with the target resource types of the element types.
+// If the reference types are enabled, the property refers to the reference component narrowed to the targets.
+func (g *Generator) applyReferenceTargets(defs Definitions, prop *Type, types []ElementDefinitionType) {
+	value := prop
+	if prop.Type == "array" && prop.Items != nil {
+		value = prop.Items
+	}
+	name := strings.TrimPrefix(value.Ref, definitionsPrefix)
+	if _, ok := g.referenceTypes[name]; name != referenceTypeName && !ok {
+		return
+	}
+	targets := g.referenceTargets(types)
+	if len(targets) == 0 {
+		return
+	}
+	prop.Extras = setExtra(prop.Extras, referenceTargetsExt, targets)
+	if g.ReferenceTypes {
+		value.Ref = definitionsPrefix + g.referenceType(defs, targets)
+	}
+}
+
+// referenceTargets returns the sorted resource types of the target profiles of the Reference types.
+// It returns nil if any resource can be referred to.
+func (g *Generator) referenceTargets(types []ElementDefinitionType) []string {
+	var targets []string
+	for _, typ := range types {
+		if typ.Code != referenceTypeName {
+			continue
+		}
+		for _, url := range typ.TargetProfile {
+			url = canonicalURL(url)
+			target := url[strings.LastIndex(url, "/")+1:]
+			if sd, ok := g.StructureDefinitions[url]; ok && sd.Type != "" {
+				target = sd.Type
+			}
+			if target == resourceTypeName {
+				return nil
+			}
+			targets = appendUnique(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// referenceType adds the reference component whose reference is narrowed to the target resource types,
+// e.g. Reference_Patient, and returns its name.
+func (g *Generator) referenceType(defs Definitions, targets []string) string {
+	name := referenceTypeName + "_" + strings.Join(targets, "_")
+	if _, ok := defs[name]; ok {
+		return name
+	}
+	pattern := "^" + targets[0] + "/"
+	if len(targets) > 1 {
+		pattern = "^(" + strings.Join(targets, "|") + ")/"
+	}
+	defs[name] = &Type{
+		Description: "A reference to " + strings.Join(targets, ", ") + ".",
+		AllOf: []*Type{
+			{Ref: definitionsPrefix + referenceTypeName},
+			{Properties: map[string]*Type{
+				referenceProperty: {Type: "string", Pattern: pattern},
+			}},
+		},
+	}
+	g.referenceTypes[name] = targets
+	return name
+}
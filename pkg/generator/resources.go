@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// LoadResources reads a FHIR resource or a Bundle of resources and registers the conformance resources
+// used by generation. Resources of unsupported types are skipped.
+func (g *Generator) LoadResources(r io.Reader) error {
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("decoding resource: %w", err)
+	}
+	return g.addResource(data)
+}
+
+func (g *Generator) addResource(data json.RawMessage) error {
+	var header struct {
+		ResourceType string `json:"resourceType"`
+		ID           string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("decoding resource: %w", err)
+	}
+
+	var err error
+	switch header.ResourceType {
+	case "Bundle":
+		var bundle Bundle
+		if err = json.Unmarshal(data, &bundle); err == nil {
+			for _, entry := range bundle.Entry {
+				if len(entry.Resource) == 0 {
+					continue
+				}
+				if err := g.addResource(entry.Resource); err != nil {
+					return err
+				}
+			}
+		}
+	case "SearchParameter":
+		var param SearchParameter
+		if err = json.Unmarshal(data, &param); err == nil {
+			g.AddSearchParameter(&param)
+		}
+	case "StructureDefinition":
+		var sd StructureDefinition
+		if err = json.Unmarshal(data, &sd); err == nil {
+			g.AddStructureDefinition(&sd)
+		}
+	case "OperationDefinition":
+		var op OperationDefinition
+		if err = json.Unmarshal(data, &op); err == nil {
+			g.AddOperationDefinition(&op)
+		}
+	case "ValueSet":
+		var vs ValueSet
+		if err = json.Unmarshal(data, &vs); err == nil {
+			g.AddValueSet(&vs)
+		}
+	case "CompartmentDefinition":
+		var cd CompartmentDefinition
+		if err = json.Unmarshal(data, &cd); err == nil {
+			g.AddCompartmentDefinition(&cd)
+		}
+	case "CodeSystem":
+		var cs CodeSystem
+		if err = json.Unmarshal(data, &cs); err == nil {
+			g.AddCodeSystem(&cs)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("decoding %s «%s»: %w", header.ResourceType, header.ID, err)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ type Schema struct {
 type Type struct {
 	// RFC draft-wright-json-schema-00
 	Version string `json:"$schema,omitempty"` // section 6.1
+	ID      string `json:"id,omitempty"`      // section 9.2
 	Ref     string `json:"$ref,omitempty"`    // section 7
 	// RFC draft-wright-json-schema-validation-00, section 5
 	MultipleOf           *float64         `json:"multipleOf,omitempty"`           // section 5.1
@@ -49,6 +50,8 @@ type Type struct {
 	OneOf                []*Type          `json:"oneOf,omitempty"`                // section 5.24
 	Not                  *Type            `json:"not,omitempty"`                  // section 5.25
 	Definitions          Definitions      `json:"definitions,omitempty"`          // section 5.26
+	// OpenAPI discriminator declared at the root of the FHIR JSON schema
+	Discriminator *Discriminator `json:"discriminator,omitempty"`
 	// RFC draft-wright-json-schema-validation-00, section 6, 7
 	Title       string        `json:"title,omitempty"`       // section 6.1
 	Description string        `json:"description,omitempty"` // section 6.1
@@ -61,6 +64,9 @@ type Type struct {
 
 	Extras map[string]interface{} `json:"-"`
 
+	// FHIR element definition the type is built from
+	Element *ElementDefinition `json:"-"`
+
 	// path element - for creating a path by traversing back to the root element
 	PathElement string `json:"-"`
 
@@ -69,3 +75,9 @@ type Type struct {
 }
 
 type AdditionalProperties Schema
+
+// Discriminator is the property name and the mapping of its values to the definitions of the polymorphic type.
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
@@ -5,15 +5,16 @@ import "github.com/getkin/kin-openapi/openapi3"
 type ParameterLocation string
 
 const (
-	InQuery ParameterLocation = "query"
-	InPath  ParameterLocation = "path"
+	InQuery  ParameterLocation = "query"
+	InPath   ParameterLocation = "path"
+	InHeader ParameterLocation = "header"
 )
 
 func NewParameterWithSchema(in ParameterLocation, name string, required bool, schema *openapi3.SchemaRef) *openapi3.ParameterRef {
 	return &openapi3.ParameterRef{Value: &openapi3.Parameter{
 		Name:     name,
-		In:       string(name),
-		Required: false,
+		In:       string(in),
+		Required: required,
 		Schema:   schema,
 	}}
 }
@@ -51,6 +52,18 @@ func NewSchemaWithFormat(typ string, format string) *openapi3.SchemaRef {
 	return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: typ, Format: format}}
 }
 
+func NewSchemaWithPattern(pattern string) *openapi3.SchemaRef {
+	return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string", Pattern: pattern}}
+}
+
+func NewSchemaEnum(values ...string) *openapi3.SchemaRef {
+	enum := make([]interface{}, len(values))
+	for i, v := range values {
+		enum[i] = v
+	}
+	return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string", Enum: enum}}
+}
+
 func NewSchemaRef(refName string) *openapi3.SchemaRef {
 	return &openapi3.SchemaRef{Ref: "#/components/schemas/" + refName}
 }
@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Search parameter types.
+const (
+	SearchNumber    = "number"
+	SearchDate      = "date"
+	SearchString    = "string"
+	SearchToken     = "token"
+	SearchReference = "reference"
+	SearchComposite = "composite"
+	SearchQuantity  = "quantity"
+	SearchURI       = "uri"
+	SearchSpecial   = "special"
+)
+
+// Bases of the search parameters that are applicable to all resources.
+const (
+	BaseResource       = "Resource"
+	BaseDomainResource = "DomainResource"
+)
+
+const (
+	searchPrefix   = "(eq|ne|gt|lt|ge|le|sa|eb|ap)?"
+	numberPattern  = "-?(0|[1-9][0-9]*)(\\.[0-9]+)?([eE][+-]?[0-9]+)?"
+	datePattern    = "[0-9]{4}(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1])(T([01][0-9]|2[0-3]):[0-5][0-9](:([0-5][0-9]|60)(\\.[0-9]+)?)?(Z|(\\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00))?)?)?)?"
+	searchTypeExt  = "x-fhir-search-type"
+	searchParamSep = "-"
+)
+
+// Search parameters that are not defined by SearchParameter resources, but are applicable to all resources.
+var specialSearchParameters = []*SearchParameter{
+	{Code: "_list", Type: SearchToken, Description: "Select resources that are members of the list."},
+	{Code: "_has", Type: SearchSpecial, Description: "Select resources based on the properties of the resources that refer to them (reverse chaining)."},
+}
+
+// Search result parameters.
+var resultParameters = []*SearchParameter{
+	{Code: "_sort", Type: SearchString, Description: "Order to sort results in."},
+	{Code: "_count", Type: SearchNumber, Description: "Number of results per page."},
+	{Code: "_include", Type: SearchString, Description: "Other resources to include in the search results that search matches point to."},
+	{Code: "_revinclude", Type: SearchString, Description: "Other resources to include in the search results when they refer to search matches."},
+	{Code: "_summary", Type: SearchToken, Description: "Just return the summary elements (for resources where this is defined)."},
+	{Code: "_total", Type: SearchToken, Description: "Request a precision of the total number of results for a request."},
+	{Code: "_elements", Type: SearchString, Description: "Request that only a specific set of elements be returned for resources."},
+	{Code: "_contained", Type: SearchToken, Description: "Whether to return resources contained in other resources in the search matches."},
+	{Code: "_containedType", Type: SearchToken, Description: "If returning contained resources, whether to return the contained or container resources."},
+}
+
+// Schemas of the result parameters that differ from the default for their search types.
+var resultParameterSchemas = map[string]*openapi3.SchemaRef{
+	"_count":         NewSchemaInteger(),
+	"_summary":       NewSchemaEnum("true", "text", "data", "count", "false"),
+	"_total":         NewSchemaEnum("none", "estimate", "accurate"),
+	"_contained":     NewSchemaEnum("true", "false", "both"),
+	"_containedType": NewSchemaEnum("container", "contained"),
+}
+
+// Type-level search parameter available at the system level.
+var typeSearchParameter = &SearchParameter{
+	Code:        "_type",
+	Type:        SearchSpecial,
+	Description: "Resource types to search across.",
+}
+
+// AddSearchParameter registers the search parameter for all of its base resources.
+// A parameter with the same code already registered for a base is replaced.
+func (g *Generator) AddSearchParameter(param *SearchParameter) {
+	for _, base := range param.Base {
+		params := g.SearchParameters[base]
+		replaced := false
+		for i, p := range params {
+			if p.Code == param.Code {
+				params[i] = param
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			params = append(params, param)
+		}
+		g.SearchParameters[base] = params
+	}
+}
+
+// initSearchParameters registers search parameters as the component parameters.
+func (g *Generator) initSearchParameters() {
+	for _, param := range g.commonSearchParameters() {
+		g.Swagger.Components.Parameters[searchParameterName("", param.Code)] = newSearchParameter(param)
+	}
+	for _, param := range g.resultParameters() {
+		g.Swagger.Components.Parameters[searchParameterName("", param.Code)] = newSearchParameter(param)
+	}
+	g.Swagger.Components.Parameters[searchParameterName("", typeSearchParameter.Code)] = newSearchParameter(typeSearchParameter)
+
+	for base, params := range g.SearchParameters {
+		if base == BaseResource || base == BaseDomainResource {
+			continue
+		}
+		for _, param := range params {
+			g.Swagger.Components.Parameters[searchParameterName(base, param.Code)] = newSearchParameter(param)
+		}
+	}
+}
+
+// resultParameters returns the search result parameters available in the FHIR version.
+func (g *Generator) resultParameters() []*SearchParameter {
+	params := make([]*SearchParameter, 0, len(resultParameters))
+	for _, param := range resultParameters {
+		if g.since(versionResultParameters[param.Code]) {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// commonSearchParameters returns the search parameters applicable to all resources.
+func (g *Generator) commonSearchParameters() []*SearchParameter {
+	params := append([]*SearchParameter{}, g.SearchParameters[BaseResource]...)
+	params = append(params, g.SearchParameters[BaseDomainResource]...)
+	return append(params, specialSearchParameters...)
+}
+
+// searchParameters returns references to the search parameters of the entity.
+// The system level parameters are returned if the entity is empty.
+// If the capability statement is loaded only the declared parameters are returned.
+// Without loaded search parameters the generic "search" parameter is returned.
+func (g *Generator) searchParameters(entity string) openapi3.Parameters {
+	if len(g.SearchParameters) == 0 {
+		return openapi3.Parameters{NewParameterRef("search")}
+	}
+
+	// Without a capability statement all the parameters are declared.
+	declared := g.declaredSearchParameters(entity)
+	isDeclared := func(code string) bool { return declared == nil || declared[code] }
+
+	var common []string
+	add := func(params []*SearchParameter) {
+		for _, param := range params {
+			if isDeclared(param.Code) {
+				common = append(common, param.Code)
+			}
+		}
+	}
+	add(g.SearchParameters[BaseResource])
+	if entity == "" || g.isDomainResource(entity) {
+		add(g.SearchParameters[BaseDomainResource])
+	}
+	add(specialSearchParameters)
+	if entity == "" {
+		add([]*SearchParameter{typeSearchParameter})
+	}
+	sort.Strings(common)
+
+	var specific []string
+	for _, param := range g.SearchParameters[entity] {
+		if isDeclared(param.Code) {
+			specific = append(specific, param.Code)
+		}
+	}
+	sort.Strings(specific)
+
+	params := make(openapi3.Parameters, 0, len(common)+len(specific)+len(resultParameters))
+	for _, code := range common {
+		params = append(params, NewParameterRef(searchParameterName("", code)))
+	}
+	for _, code := range specific {
+		params = append(params, NewParameterRef(searchParameterName(entity, code)))
+	}
+	for _, param := range g.resultParameters() {
+		params = append(params, NewParameterRef(searchParameterName("", param.Code)))
+	}
+	return params
+}
+
+// searchParameterName returns the component name of the search parameter.
+func searchParameterName(base, code string) string {
+	if base == "" {
+		return code
+	}
+	return base + searchParamSep + code
+}
+
+func newSearchParameter(param *SearchParameter) *openapi3.ParameterRef {
+	p := NewParameterWithSchema(InQuery, param.Code, false, searchParameterSchema(param))
+	p.Value.Description = param.Description
+	p.Value.Extensions = map[string]interface{}{searchTypeExt: param.Type}
+	return p
+}
+
+// searchParameterSchema returns the schema of the parameter value according to the search parameter type.
+func searchParameterSchema(param *SearchParameter) *openapi3.SchemaRef {
+	if schema, ok := resultParameterSchemas[param.Code]; ok && param.Base == nil {
+		return schema
+	}
+	switch param.Type {
+	case SearchNumber:
+		return NewSchemaWithPattern("^" + searchPrefix + numberPattern + "$")
+	case SearchDate:
+		return NewSchemaWithPattern("^" + searchPrefix + datePattern + "$")
+	case SearchQuantity:
+		return NewSchemaWithPattern("^" + searchPrefix + numberPattern + "(\\|[^|]*\\|[^|]*)?$")
+	default:
+		return NewSchemaString()
+	}
+}
@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// extCategory is the extension of the structure definition with the resource category, e.g. Clinical.Diagnostics.
+const extCategory = "http://hl7.org/fhir/StructureDefinition/structuredefinition-category"
+
+var componentRefRegexp = regexp.MustCompile(`"#/components/(schemas|responses|parameters|requestBodies)/([^"]+)"`)
+
+// isShaking checks that the resources are selected, so the unreachable components are pruned.
+func (g *Generator) isShaking() bool {
+	return len(g.Include) > 0 || len(g.Exclude) > 0 || len(g.Categories) > 0
+}
+
+// isSelected checks that the paths of the entity are generated.
+// The entity is selected if it matches any include pattern or category and does not match the exclude patterns.
+// All entities are selected if there are no include patterns and categories.
+func (g *Generator) isSelected(entity string) bool {
+	if matchAny(g.Exclude, entity) {
+		return false
+	}
+	if len(g.Include) == 0 && len(g.Categories) == 0 {
+		return true
+	}
+	return matchAny(g.Include, entity) || g.inCategories(entity)
+}
+
+// matchAny checks that the name matches any glob pattern.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inCategories checks that the resource category matches any selected category, e.g. Clinical matches Clinical.Diagnostics.
+func (g *Generator) inCategories(entity string) bool {
+	category := strings.ToLower(g.resourceCategory(entity))
+	if category == "" {
+		return false
+	}
+	for _, c := range g.Categories {
+		c = strings.ToLower(c)
+		if category == c || strings.HasPrefix(category, c+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceCategory returns the category of the resource from its base structure definition.
+func (g *Generator) resourceCategory(entity string) string {
+	for _, sd := range g.StructureDefinitions {
+		if sd.Type != entity || sd.Kind != KindResource || sd.Derivation == DerivationConstraint {
+			continue
+		}
+		for _, ext := range sd.Extension {
+			if ext.URL == extCategory {
+				return ext.ValueString
+			}
+		}
+	}
+	return ""
+}
+
+// selectResources restricts the resource list to the selected resources.
+func (g *Generator) selectResources() {
+	if !g.isShaking() {
+		return
+	}
+	if len(g.Categories) > 0 && len(g.StructureDefinitions) == 0 {
+		g.warnf("resource categories are taken from the structure definitions, but they are not loaded")
+	}
+	list, ok := g.Schema.Definitions[resourceListName]
+	if !ok {
+		return
+	}
+	var oneOf []*Type
+	for _, t := range list.OneOf {
+		if g.isSelected(strings.TrimPrefix(t.Ref, definitionsPrefix)) {
+			oneOf = append(oneOf, t)
+		}
+	}
+	list.OneOf = oneOf
+}
+
+// prune removes the component schemas, responses and parameters that are not reachable from the paths,
+// the selected resources and their profiles.
+func (g *Generator) prune() {
+	if !g.isShaking() {
+		return
+	}
+	components := &g.Swagger.Components
+	reachable := map[string]map[string]bool{"schemas": {}, "responses": {}, "parameters": {}, "requestBodies": {}}
+	var queue [][2]string
+	visit := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		for _, m := range componentRefRegexp.FindAllStringSubmatch(string(data), -1) {
+			if !reachable[m[1]][m[2]] {
+				reachable[m[1]][m[2]] = true
+				queue = append(queue, [2]string{m[1], m[2]})
+			}
+		}
+	}
+
+	visit(g.Swagger.Paths)
+	for _, name := range g.resourceNames() {
+		if g.isSelected(name) {
+			visit(g.schemaRef(name))
+		}
+	}
+	for name, sd := range g.profiles {
+		if g.isSelected(sd.Type) {
+			visit(g.schemaRef(name))
+		}
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		switch ref[0] {
+		case "schemas":
+			visit(components.Schemas[ref[1]])
+		case "responses":
+			visit(components.Responses[ref[1]])
+		case "parameters":
+			visit(components.Parameters[ref[1]])
+		case "requestBodies":
+			visit(components.RequestBodies[ref[1]])
+		}
+	}
+
+	for name := range components.Schemas {
+		if !reachable["schemas"][name] {
+			delete(components.Schemas, name)
+		}
+	}
+	for name := range components.Responses {
+		if !reachable["responses"][name] {
+			delete(components.Responses, name)
+		}
+	}
+	for name := range components.Parameters {
+		if !reachable["parameters"][name] {
+			delete(components.Parameters, name)
+		}
+	}
+	for name := range components.RequestBodies {
+		if !reachable["requestBodies"][name] {
+			delete(components.RequestBodies, name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gotidy/ptr"
+)
+
+const (
+	searchPath      = "_search"
+	formContentType = "application/x-www-form-urlencoded"
+)
+
+// strictInteractions are the interactions generated in the strict mode without a capability statement.
+var strictInteractions = map[string]bool{
+	InteractionRead:            true,
+	InteractionVRead:           true,
+	InteractionUpdate:          true,
+	InteractionPatch:           true,
+	InteractionDelete:          true,
+	InteractionHistoryInstance: true,
+	InteractionHistoryType:     true,
+	InteractionCreate:          true,
+	InteractionSearchType:      true,
+	InteractionTransaction:     true,
+	InteractionBatch:           true,
+	InteractionSearchSystem:    true,
+	InteractionHistorySystem:   true,
+}
+
+// interactionStatuses are the HTTP status codes of the responses of the interactions in the strict mode.
+var interactionStatuses = map[string][]int{
+	InteractionRead:   {http.StatusOK, http.StatusNotModified, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusGone},
+	InteractionVRead:  {http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusGone},
+	InteractionCreate: {http.StatusOK, http.StatusCreated, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnprocessableEntity},
+	InteractionUpdate: {http.StatusOK, http.StatusCreated, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity},
+	InteractionPatch:  {http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity},
+}
+
+// isStrictDefault checks that all interactions of the FHIR RESTful API are generated,
+// that is the strict mode is enabled and there is no capability statement.
+func (g *Generator) isStrictDefault() bool {
+	return g.Strict && g.Capability == nil
+}
+
+// interactionResponses returns the responses of the interaction in the strict mode.
+// The successful responses have the body of the response resp, the errors have the OperationOutcome body.
+func interactionResponses(interaction string, resp, respErr *openapi3.ResponseRef) openapi3.Responses {
+	responses := make(openapi3.Responses, len(interactionStatuses[interaction]))
+	for _, status := range interactionStatuses[interaction] {
+		code := strconv.Itoa(status)
+		switch {
+		case status == http.StatusNotModified:
+			responses[code] = &openapi3.ResponseRef{Value: &openapi3.Response{Description: ptr.String(http.StatusText(status))}}
+		case status < http.StatusMultipleChoices:
+			responses[code] = resp
+		default:
+			responses[code] = respErr
+		}
+	}
+	return responses
+}
+
+// searchOperation returns the search interaction using POST with the form-encoded parameters
+// of the entity or the system if the entity is empty.
+func (g *Generator) searchOperation(entity string, respBundle, respErr *openapi3.ResponseRef) *openapi3.Operation {
+	tags := []string{"search"}
+	if entity != "" {
+		tags = []string{entity}
+	}
+	return &openapi3.Operation{
+		Description: "This searches resources using the criteria represented in the form-encoded body.",
+		Tags:        tags,
+		RequestBody: &openapi3.RequestBodyRef{Value: &openapi3.RequestBody{
+			Content: openapi3.Content{formContentType: &openapi3.MediaType{Schema: g.searchFormSchema(g.searchParameters(entity))}},
+		}},
+		Responses: openapi3.Responses{
+			"200": respBundle,
+			"400": respErr,
+			"401": respErr,
+			"403": respErr,
+			"404": respErr,
+		},
+	}
+}
+
+// searchFormSchema returns the schema of the form of the search parameters.
+// The other properties are allowed for the modifiers and the chained parameters.
+func (g *Generator) searchFormSchema(params openapi3.Parameters) *openapi3.SchemaRef {
+	schema := &openapi3.Schema{
+		Type:                        "object",
+		Properties:                  make(openapi3.Schemas, len(params)),
+		AdditionalPropertiesAllowed: ptr.Bool(true),
+	}
+	for _, ref := range params {
+		param := ref.Value
+		if ref.Ref != "" {
+			if p, ok := g.Swagger.Components.Parameters[strings.TrimPrefix(ref.Ref, "#/components/parameters/")]; ok {
+				param = p.Value
+			}
+		}
+		if param == nil || param.Schema == nil {
+			continue
+		}
+		// The legacy search parameter is the object of all parameters.
+		if param.Schema.Value != nil && param.Schema.Value.Type == "object" {
+			return param.Schema
+		}
+		schema.Properties[param.Name] = param.Schema
+	}
+	return openapi3.NewSchemaRef("", schema)
+}
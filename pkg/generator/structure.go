@@ -0,0 +1,295 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	definitionsPrefix = "#/definitions/"
+	resourceListName  = "ResourceList"
+	elementName       = "Element"
+	resourceTypeName  = "Resource"
+	extensionsPrefix  = "_"
+
+	extRegex         = "http://hl7.org/fhir/StructureDefinition/regex"
+	extFHIRType      = "http://hl7.org/fhir/StructureDefinition/structuredefinition-fhir-type"
+	systemTypePrefix = "http://hl7.org/fhirpath/System."
+	choiceSuffix     = "[x]"
+)
+
+// systemType is a FHIRPath system type used by the value elements of the primitive types.
+type systemType struct {
+	Primitive string
+	JSON      string
+}
+
+var systemTypes = map[string]systemType{
+	"String":   {Primitive: "string", JSON: "string"},
+	"Boolean":  {Primitive: "boolean", JSON: "boolean"},
+	"Integer":  {Primitive: "integer", JSON: "integer"},
+	"Decimal":  {Primitive: "decimal", JSON: "number"},
+	"Date":     {Primitive: "date", JSON: "string"},
+	"DateTime": {Primitive: "dateTime", JSON: "string"},
+	"Time":     {Primitive: "time", JSON: "string"},
+}
+
+// AddStructureDefinition registers the structure definition.
+// A definition with the same canonical URL already registered is replaced.
+func (g *Generator) AddStructureDefinition(sd *StructureDefinition) {
+	key := sd.URL
+	if key == "" {
+		key = sd.Name
+	}
+	g.StructureDefinitions[key] = sd
+}
+
+// baseStructureDefinitions returns the sorted by type structure definitions of the base types and resources.
+func (g *Generator) baseStructureDefinitions() []*StructureDefinition {
+	var sds []*StructureDefinition
+	for _, sd := range g.StructureDefinitions {
+		if sd.Derivation == DerivationConstraint || sd.Kind == KindLogical || sd.Snapshot == nil {
+			continue
+		}
+		sds = append(sds, sd)
+	}
+	sort.Slice(sds, func(i, j int) bool { return sds[i].Type < sds[j].Type })
+	return sds
+}
+
+// convertStructureDefinitions builds the schema definitions from the snapshots of the base structure definitions.
+// The definitions have the same layout as the definitions of the FHIR JSON schema.
+func (g *Generator) convertStructureDefinitions() (Definitions, error) {
+	defs := make(Definitions)
+	var resources []string
+	for _, sd := range g.baseStructureDefinitions() {
+		switch sd.Kind {
+		case KindPrimitiveType:
+			defs[sd.Type] = primitiveDefinition(sd)
+		case KindComplexType, KindResource:
+			if sd.Kind == KindResource && sd.Abstract {
+				continue
+			}
+			if err := newStructureConverter(sd, defs).convert(); err != nil {
+				return nil, err
+			}
+			if sd.Kind == KindResource {
+				resources = append(resources, sd.Type)
+			}
+		}
+	}
+
+	if len(resources) > 0 {
+		list := &Type{}
+		for _, name := range resources {
+			list.OneOf = append(list.OneOf, &Type{Ref: definitionsPrefix + name})
+		}
+		defs[resourceListName] = list
+	}
+	g.applyBindings(defs)
+	g.applyReferences(defs)
+	g.annotateElements(defs)
+	return defs, nil
+}
+
+// primitiveDefinition builds the definition of the primitive type from the type of its value element.
+func primitiveDefinition(sd *StructureDefinition) *Type {
+	def := &Type{Description: sd.Description}
+	for _, e := range sd.Snapshot.Element {
+		if e.Path != sd.Type+".value" {
+			continue
+		}
+		for _, typ := range e.Type {
+			if st, ok := systemTypes[strings.TrimPrefix(typ.Code, systemTypePrefix)]; ok {
+				def.Type = st.JSON
+			}
+			for _, ext := range typ.Extension {
+				if ext.URL == extRegex && ext.ValueString != "" {
+					def.Pattern = "^" + ext.ValueString + "$"
+				}
+			}
+		}
+	}
+	return def
+}
+
+// structureConverter converts the snapshot of a complex type or resource into the definitions.
+type structureConverter struct {
+	sd   *StructureDefinition
+	defs Definitions
+	// names are the definition names by element paths of the root and backbone elements.
+	names map[string]string
+}
+
+func newStructureConverter(sd *StructureDefinition, defs Definitions) *structureConverter {
+	return &structureConverter{sd: sd, defs: defs, names: make(map[string]string)}
+}
+
+func (c *structureConverter) convert() error {
+	elements := c.sd.Snapshot.Element
+
+	// Elements that have children are defined separately.
+	parents := make(map[string]bool)
+	for _, e := range elements {
+		if i := strings.LastIndex(e.Path, "."); i >= 0 {
+			parents[e.Path[:i]] = true
+		}
+	}
+	for _, e := range elements {
+		if e.SliceName != "" || e.ContentReference != "" || (!parents[e.Path] && e.Path != c.sd.Type) {
+			continue
+		}
+		name := c.sd.Type
+		if e.Path != c.sd.Type {
+			name = c.backboneName(e.Path)
+		}
+		c.names[e.Path] = name
+		c.defs[name] = &Type{
+			Description:          e.Definition,
+			Properties:           make(map[string]*Type),
+			AdditionalProperties: []byte("false"),
+			Element:              e,
+		}
+	}
+
+	root := c.defs[c.sd.Type]
+	if root == nil {
+		return nil
+	}
+	if c.sd.Kind == KindResource {
+		root.Description = c.sd.Description
+		root.Properties[resourceTypeProperty] = &Type{Description: "This is a " + c.sd.Type + " resource", Const: c.sd.Type}
+		root.Required = append(root.Required, resourceTypeProperty)
+	}
+
+	for _, e := range elements {
+		i := strings.LastIndex(e.Path, ".")
+		if i < 0 || e.SliceName != "" || e.Max == "0" {
+			continue
+		}
+		owner, ok := c.defs[c.names[e.Path[:i]]]
+		if !ok {
+			continue
+		}
+		if err := c.addProperties(owner, e.Path[i+1:], e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backboneName returns the definition name of the backbone element.
+// The name consists of the type name and the element name, a number is added to the name on conflict.
+func (c *structureConverter) backboneName(path string) string {
+	name := c.sd.Type + "_" + upperFirst(path[strings.LastIndex(path, ".")+1:])
+	if _, ok := c.defs[name]; !ok {
+		return name
+	}
+	for i := 1; ; i++ {
+		if _, ok := c.defs[name+strconv.Itoa(i)]; !ok {
+			return name + strconv.Itoa(i)
+		}
+	}
+}
+
+// addProperties adds the properties of the element to the owner definition.
+// Choice elements produce a property per type, primitive elements produce the extensions property as well.
+// The content reference must refer to the root or a backbone element of the structure.
+func (c *structureConverter) addProperties(owner *Type, name string, e *ElementDefinition) error {
+	if ref := e.ContentReference; ref != "" {
+		def, ok := c.names[ref[strings.Index(ref, "#")+1:]]
+		if !ok {
+			return fmt.Errorf("structure definition «%s»: content reference «%s» of the element «%s» is not resolved",
+				c.sd.URL, ref, e.Path)
+		}
+		c.addProperty(owner, name, e, definitionsPrefix+def, e.Min > 0)
+		return nil
+	}
+	if def, ok := c.names[e.Path]; ok {
+		c.addProperty(owner, name, e, definitionsPrefix+def, e.Min > 0)
+		return nil
+	}
+
+	choice := strings.HasSuffix(name, choiceSuffix)
+	for _, typ := range e.Type {
+		code := elementTypeCode(typ)
+		propName := name
+		if choice {
+			propName = strings.TrimSuffix(name, choiceSuffix) + upperFirst(code)
+		}
+		ref := definitionsPrefix + code
+		if code == resourceTypeName {
+			ref = definitionsPrefix + resourceListName
+		}
+		primitive := isPrimitiveType(code)
+		c.addProperty(owner, propName, e, ref, e.Min > 0 && !choice && !primitive)
+		if primitive {
+			ext := property(e, definitionsPrefix+elementName)
+			ext.Description = "Extensions for " + propName
+			owner.Properties[extensionsPrefix+propName] = ext
+		}
+	}
+	return nil
+}
+
+func (c *structureConverter) addProperty(owner *Type, name string, e *ElementDefinition, ref string, required bool) {
+	owner.Properties[name] = property(e, ref)
+	if required {
+		owner.Required = append(owner.Required, name)
+	}
+}
+
+// property returns the property definition referencing the type according to the element cardinality.
+func property(e *ElementDefinition, ref string) *Type {
+	prop := &Type{Ref: ref}
+	if e.Max != "1" {
+		prop = &Type{Type: "array", Items: prop, MinItems: uint64(e.Min)}
+		if max, err := strconv.ParseUint(e.Max, 10, 64); err == nil {
+			prop.MaxItems = &max
+		}
+	}
+	prop.Description = e.Definition
+	prop.Element = e
+	return prop
+}
+
+// elementTypeCode returns the FHIR type code of the element type.
+// FHIRPath system types are resolved to the FHIR primitive types.
+func elementTypeCode(typ ElementDefinitionType) string {
+	if !strings.HasPrefix(typ.Code, systemTypePrefix) {
+		return typ.Code
+	}
+	for _, ext := range typ.Extension {
+		if ext.URL != extFHIRType {
+			continue
+		}
+		if ext.ValueURL != "" {
+			return ext.ValueURL
+		}
+		if ext.ValueURI != "" {
+			return ext.ValueURI
+		}
+	}
+	if st, ok := systemTypes[strings.TrimPrefix(typ.Code, systemTypePrefix)]; ok {
+		return st.Primitive
+	}
+	return typ.Code
+}
+
+// isPrimitiveType checks that the FHIR type is primitive, primitive type names start with lowercase letter.
+func isPrimitiveType(code string) bool {
+	r := []rune(code)
+	return len(r) > 0 && unicode.IsLower(r[0])
+}
+
+func upperFirst(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -25,33 +25,58 @@ func (t *TypeMapper) Exists(name string) bool {
 	return ok
 }
 
+// Convert replaces the reference to a mapped type with the whole mapped type.
+// The annotations of the usage site (description, enum, const, default, examples and extensions) are kept.
 func (t *TypeMapper) Convert(schema *Type) {
-	if to, ok := t.refs[schema.Ref]; ok {
-		// *schema.Type = *to
-		schema.Ref = ""
-		schema.Type = to.Type
-		schema.Format = to.Format
+	to, ok := t.refs[schema.Ref]
+	if !ok {
+		return
 	}
+	site := *schema
+	*schema = *to.clone()
+	schema.Description = site.Description
+	schema.Element = site.Element
+	schema.PathElement = site.PathElement
+	if site.Enum != nil {
+		schema.Enum = site.Enum
+	}
+	if site.Const != nil {
+		schema.Const = site.Const
+	}
+	if site.Default != nil {
+		schema.Default = site.Default
+	}
+	if site.Examples != nil {
+		schema.Examples = site.Examples
+	}
+	extras := site.Extras
+	for name, value := range schema.Extras {
+		extras = setExtra(extras, name, value)
+	}
+	schema.Extras = extras
 }
 
-var mapper = NewTypeMapper("#/definitions/").
-	Add("id", &Type{Type: "string"}).
-	Add("string", &Type{Type: "string"}).
-	Add("base64Binary", &Type{Type: "string", Format: "byte"}).
-	Add("boolean", &Type{Type: "boolean"}).
-	Add("canonical", &Type{Type: "string"}).
-	Add("code", &Type{Type: "string"}).
-	Add("date", &Type{Type: "string", Format: "date"}).
-	Add("dateTime", &Type{Type: "string", Format: "date-time"}).
-	Add("decimal", &Type{Type: "number"}).
-	Add("instant", &Type{Type: "string"}).
-	Add("integer", &Type{Type: "integer"}).
-	Add("markdown", &Type{Type: "string"}).
-	Add("oid", &Type{Type: "string"}).
-	Add("positiveInt", &Type{Type: "integer"}).
-	Add("time", &Type{Type: "string", Pattern: "^([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\\.[0-9]+)?$"}).
-	Add("unsignedInt", &Type{Type: "integer"}).
-	Add("uri", &Type{Type: "string", Format: "uri"}).
-	Add("url", &Type{Type: "string", Format: "uri"}).
-	Add("uuid", &Type{Type: "uuid", Format: "uuid"}).
-	Add("xhtml", &Type{Type: "string"})
+// NewLossyTypeMapper returns the mapper of the primitive types to the OpenAPI types and formats friendly for code generation.
+func NewLossyTypeMapper() *TypeMapper {
+	return NewTypeMapper(definitionsPrefix).
+		Add("id", &Type{Type: "string"}).
+		Add("string", &Type{Type: "string"}).
+		Add("base64Binary", &Type{Type: "string", Format: "byte"}).
+		Add("boolean", &Type{Type: "boolean"}).
+		Add("canonical", &Type{Type: "string"}).
+		Add("code", &Type{Type: "string"}).
+		Add("date", &Type{Type: "string", Format: "date"}).
+		Add("dateTime", &Type{Type: "string", Format: "date-time"}).
+		Add("decimal", &Type{Type: "number"}).
+		Add("instant", &Type{Type: "string"}).
+		Add("integer", &Type{Type: "integer"}).
+		Add("markdown", &Type{Type: "string"}).
+		Add("oid", &Type{Type: "string"}).
+		Add("positiveInt", &Type{Type: "integer"}).
+		Add("time", &Type{Type: "string", Pattern: "^([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\\.[0-9]+)?$"}).
+		Add("unsignedInt", &Type{Type: "integer"}).
+		Add("uri", &Type{Type: "string", Format: "uri"}).
+		Add("url", &Type{Type: "string", Format: "uri"}).
+		Add("uuid", &Type{Type: "string", Format: "uuid"}).
+		Add("xhtml", &Type{Type: "string"})
+}
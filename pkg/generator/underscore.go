@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrimitiveExtensionStrategy is the strategy of the properties holding the id and the extensions of the primitive properties,
+// e.g. _birthDate of birthDate.
+type PrimitiveExtensionStrategy string
+
+// Primitive extension strategies.
+const (
+	// PrimitiveExtensionsDrop drops the primitive extension properties.
+	PrimitiveExtensionsDrop PrimitiveExtensionStrategy = "drop"
+	// PrimitiveExtensionsKeep keeps the primitive extension properties as the siblings of the primitive properties.
+	PrimitiveExtensionsKeep PrimitiveExtensionStrategy = "keep"
+	// PrimitiveExtensionsFold drops the primitive extension properties and annotates the primitive properties with their schemas.
+	PrimitiveExtensionsFold PrimitiveExtensionStrategy = "fold"
+)
+
+const (
+	primitiveExtensionExt = "x-fhir-primitive-extension"
+	// goNameExt sets the Go field name, so the primitive and its extension properties do not collide.
+	goNameExt           = "x-go-name"
+	goNameExtensionPart = "Extension"
+)
+
+// ParsePrimitiveExtensionStrategy parses the primitive extension strategy.
+func ParsePrimitiveExtensionStrategy(s string) (PrimitiveExtensionStrategy, error) {
+	switch strategy := PrimitiveExtensionStrategy(strings.ToLower(s)); strategy {
+	case PrimitiveExtensionsDrop, PrimitiveExtensionsKeep, PrimitiveExtensionsFold:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown primitive extension strategy «%s»", s)
+}
+
+// primitiveExtensions returns the primitive extension strategy, SkipUnderscore selects it if it is not set.
+func (g *Generator) primitiveExtensions() PrimitiveExtensionStrategy {
+	if g.PrimitiveExtensions != "" {
+		return g.PrimitiveExtensions
+	}
+	if g.SkipUnderscore {
+		return PrimitiveExtensionsDrop
+	}
+	return PrimitiveExtensionsKeep
+}
+
+// primitiveExtensionOf returns the name of the primitive property of the primitive extension property.
+func primitiveExtensionOf(name string, props map[string]*Type) (string, bool) {
+	if len(name) < 2 || !strings.HasPrefix(name, extensionsPrefix) {
+		return "", false
+	}
+	_, ok := props[name[1:]]
+	return name[1:], ok
+}
+
+// isSkipped checks that the property is the primitive extension property that is not generated.
+func (g *Generator) isSkipped(name string, props map[string]*Type) bool {
+	_, ok := primitiveExtensionOf(name, props)
+	return ok && g.primitiveExtensions() != PrimitiveExtensionsKeep
+}
+
+// foldPrimitiveExtension returns the property annotated with the schema of its primitive extension property
+// if the primitive extensions are folded. Kept primitive extension properties are annotated with the Go names.
+func (g *Generator) foldPrimitiveExtension(name string, prop *Type, props map[string]*Type) *Type {
+	switch g.primitiveExtensions() {
+	case PrimitiveExtensionsKeep:
+		if primitive, ok := primitiveExtensionOf(name, props); ok {
+			t := *prop
+			t.Extras = copyExtras(prop.Extras)
+			t.Extras[goNameExt] = upperFirst(primitive) + goNameExtensionPart
+			return &t
+		}
+	case PrimitiveExtensionsFold:
+		if ext, ok := props[extensionsPrefix+name]; ok {
+			t := *prop
+			t.Extras = copyExtras(prop.Extras)
+			t.Extras[primitiveExtensionExt] = map[string]interface{}{
+				"name":   extensionsPrefix + name,
+				"schema": g.convertSchema(ext),
+			}
+			return &t
+		}
+	}
+	return prop
+}
+
+func copyExtras(extras map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(extras)+1)
+	for k, v := range extras {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+)
+
+// bindingExt is the extension with the value set binding of the coded element.
+const bindingExt = "x-fhir-binding"
+
+// AddValueSet registers the value set.
+// A value set with the same canonical URL already registered is replaced.
+func (g *Generator) AddValueSet(vs *ValueSet) {
+	key := vs.URL
+	if key == "" {
+		key = vs.Name
+	}
+	g.ValueSets[key] = vs
+}
+
+// AddCodeSystem registers the code system.
+// A code system with the same canonical URL already registered is replaced.
+func (g *Generator) AddCodeSystem(cs *CodeSystem) {
+	key := cs.URL
+	if key == "" {
+		key = cs.Name
+	}
+	g.CodeSystems[key] = cs
+}
+
+// applyBindings applies the value set bindings of the elements to the properties of the definitions.
+// The required bindings of the codes are converted into the enums if the value sets can be expanded,
+// other bindings are annotated with the binding extension. The extensions properties of the primitive elements,
+// e.g. _gender, share the element definitions but are not bound.
+func (g *Generator) applyBindings(defs Definitions) {
+	for _, def := range defs {
+		for name, prop := range def.Properties {
+			if strings.HasPrefix(name, extensionsPrefix) {
+				continue
+			}
+			if prop.Element != nil && prop.Element.Binding != nil {
+				g.applyBinding(prop, prop.Element.Binding)
+			}
+		}
+	}
+}
+
+// applyBinding applies the binding to the property or its items.
+func (g *Generator) applyBinding(prop *Type, binding *ElementDefinitionBinding) {
+	if binding.ValueSet == "" || binding.Strength == BindingExample {
+		return
+	}
+	value := prop
+	if prop.Type == "array" && prop.Items != nil {
+		value = prop.Items
+	}
+	// The code is either the reference or the enum of the already bound code.
+	isCode := value.Ref == definitionsPrefix+"code" || (value.Ref == "" && value.Type == "string" && len(value.Enum) > 0)
+	if binding.Strength == BindingRequired && isCode {
+		if codes := g.expandValueSet(binding.ValueSet); len(codes) > 0 {
+			value.Ref = ""
+			value.Type = "string"
+			value.Enum = value.Enum[:0]
+			for _, code := range codes {
+				value.Enum = append(value.Enum, code)
+			}
+			delete(prop.Extras, bindingExt)
+			return
+		}
+	}
+	prop.Extras = setExtra(prop.Extras, bindingExt, map[string]interface{}{
+		"valueSet": binding.ValueSet,
+		"strength": binding.Strength,
+	})
+}
+
+// expandValueSet returns the sorted codes of the value set.
+// It returns nil if the value set is not loaded or can not be expanded completely.
+func (g *Generator) expandValueSet(url string) []string {
+	codes, ok := g.valueSetCodes(url, make(map[string]bool))
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(codes))
+	for code := range codes {
+		result = append(result, code)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// valueSetCodes returns the codes of the value set from its expansion or its compose definition.
+// Value set composes with filters are not expanded.
+func (g *Generator) valueSetCodes(url string, visited map[string]bool) (map[string]bool, bool) {
+	url = canonicalURL(url)
+	vs, ok := g.ValueSets[url]
+	if !ok || visited[url] {
+		return nil, false
+	}
+	visited[url] = true
+
+	codes := make(map[string]bool)
+	if vs.Expansion != nil && len(vs.Expansion.Contains) > 0 {
+		addContainedCodes(codes, vs.Expansion.Contains)
+		return codes, true
+	}
+	if vs.Compose == nil || len(vs.Compose.Include) == 0 {
+		return nil, false
+	}
+	for _, include := range vs.Compose.Include {
+		included, ok := g.includedCodes(include, visited)
+		if !ok {
+			return nil, false
+		}
+		for code := range included {
+			codes[code] = true
+		}
+	}
+	for _, exclude := range vs.Compose.Exclude {
+		excluded, ok := g.includedCodes(exclude, visited)
+		if !ok {
+			return nil, false
+		}
+		for code := range excluded {
+			delete(codes, code)
+		}
+	}
+	return codes, true
+}
+
+// includedCodes returns the codes selected by the compose include or exclude element.
+func (g *Generator) includedCodes(include ValueSetInclude, visited map[string]bool) (map[string]bool, bool) {
+	if len(include.Filter) > 0 {
+		return nil, false
+	}
+	var codes map[string]bool
+	switch {
+	case len(include.Concept) > 0:
+		codes = make(map[string]bool, len(include.Concept))
+		for _, c := range include.Concept {
+			codes[c.Code] = true
+		}
+	case include.System != "":
+		cs, ok := g.CodeSystems[include.System]
+		if !ok || cs.Content != CodeSystemContentComplete {
+			return nil, false
+		}
+		codes = make(map[string]bool)
+		addConceptCodes(codes, cs.Concept)
+	}
+	// Codes are the intersection of the codes of the system and the value sets.
+	for _, url := range include.ValueSet {
+		vsCodes, ok := g.valueSetCodes(url, visited)
+		if !ok {
+			return nil, false
+		}
+		if codes == nil {
+			codes = vsCodes
+			continue
+		}
+		for code := range codes {
+			if !vsCodes[code] {
+				delete(codes, code)
+			}
+		}
+	}
+	return codes, codes != nil
+}
+
+func addContainedCodes(codes map[string]bool, contains []ValueSetContains) {
+	for _, c := range contains {
+		if c.Code != "" && !c.Abstract {
+			codes[c.Code] = true
+		}
+		addContainedCodes(codes, c.Contains)
+	}
+}
+
+func addConceptCodes(codes map[string]bool, concepts []CodeSystemConcept) {
+	for _, c := range concepts {
+		codes[c.Code] = true
+		addConceptCodes(codes, c.Concept)
+	}
+}
+
+// canonicalURL returns the canonical URL without the version.
+func canonicalURL(url string) string {
+	if i := strings.Index(url, "|"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FHIRVersion is the FHIR release as the major and minor version, e.g. "4.0".
+type FHIRVersion string
+
+// Supported FHIR releases.
+const (
+	STU3 FHIRVersion = "3.0"
+	R4   FHIRVersion = "4.0"
+	R4B  FHIRVersion = "4.3"
+	R5   FHIRVersion = "5.0"
+)
+
+// DefaultFHIRVersion is the version used if it is neither set nor detected.
+const DefaultFHIRVersion = R4
+
+// jsonSchemaPrefix is the prefix of the FHIR JSON schema id followed by the version, e.g. http://hl7.org/fhir/json-schema/4.0.
+const jsonSchemaPrefix = "http://hl7.org/fhir/json-schema/"
+
+var versionNames = map[string]FHIRVersion{
+	"STU3": STU3,
+	"R3":   STU3,
+	"R4":   R4,
+	"R4B":  R4B,
+	"R5":   R5,
+}
+
+// ParseFHIRVersion parses the release name (STU3, R4, R4B, R5) or the version number, e.g. 4.0.1.
+func ParseFHIRVersion(s string) (FHIRVersion, error) {
+	if v, ok := versionNames[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) >= 2 {
+		v := FHIRVersion(parts[0] + "." + parts[1])
+		for _, known := range versionNames {
+			if v == known {
+				return v, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported FHIR version «%s»", s)
+}
+
+// detectVersion detects the FHIR version by the JSON schema id, the structure definitions
+// or the capability statement.
+func (g *Generator) detectVersion() FHIRVersion {
+	if strings.HasPrefix(g.Schema.ID, jsonSchemaPrefix) {
+		if v, err := ParseFHIRVersion(strings.TrimPrefix(g.Schema.ID, jsonSchemaPrefix)); err == nil {
+			return v
+		}
+	}
+	for _, sd := range g.baseStructureDefinitions() {
+		if v, err := ParseFHIRVersion(sd.FHIRVersion); err == nil {
+			return v
+		}
+	}
+	if g.Capability != nil {
+		if v, err := ParseFHIRVersion(g.Capability.FHIRVersion); err == nil {
+			return v
+		}
+	}
+	return DefaultFHIRVersion
+}
+
+// Interactions introduced by the FHIR versions, the interactions that are not listed are supported by all versions.
+var versionInteractions = map[string]FHIRVersion{
+	InteractionDeleteHistory:        R5,
+	InteractionDeleteHistoryVersion: R5,
+}
+
+// Search result parameters introduced by the FHIR versions.
+var versionResultParameters = map[string]FHIRVersion{
+	"_total": R4,
+}
+
+// since checks that the feature introduced by the version is available in the generated version.
+func (g *Generator) since(v FHIRVersion) bool {
+	return v == "" || g.Version >= v
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gotidy/ptr"
+)
+
+const (
+	capabilityStatementName = "CapabilityStatement"
+	smartConfigurationName  = "SMARTConfiguration"
+	udapMetadataName        = "UDAPMetadata"
+	metadataTag             = "metadata"
+)
+
+// capabilitiesModes are the values of the mode parameter of the capabilities interaction.
+var capabilitiesModes = []string{"full", "normal", "terminology"}
+
+// smartConfigurationProperties are the properties of the SMART App Launch configuration by types.
+var smartConfigurationProperties = map[string][]string{
+	"uri": {
+		"issuer", "jwks_uri", "authorization_endpoint", "token_endpoint", "registration_endpoint",
+		"management_endpoint", "introspection_endpoint", "revocation_endpoint",
+	},
+	"array": {
+		"grant_types_supported", "token_endpoint_auth_methods_supported", "scopes_supported",
+		"response_types_supported", "capabilities", "code_challenge_methods_supported",
+	},
+}
+
+// udapMetadataProperties are the properties of the UDAP server metadata by types.
+var udapMetadataProperties = map[string][]string{
+	"uri": {"authorization_endpoint", "token_endpoint", "registration_endpoint"},
+	"array": {
+		"udap_versions_supported", "udap_profiles_supported",
+		"udap_authorization_extensions_supported", "udap_authorization_extensions_required",
+		"udap_certifications_supported", "udap_certifications_required",
+		"grant_types_supported", "scopes_supported", "token_endpoint_auth_methods_supported",
+		"token_endpoint_auth_signing_alg_values_supported", "registration_endpoint_jwt_signing_alg_values_supported",
+	},
+	"string": {"signed_metadata"},
+}
+
+// addWellKnownPathes adds the capabilities interaction and the discovery endpoints of SMART App Launch and UDAP
+// if they are enabled. The capabilities interaction does not depend on the selected resources, the pruning keeps
+// the CapabilityStatement component by its reference. The endpoints do not require the authentication.
+func (g *Generator) addWellKnownPathes() error {
+	respErr := &openapi3.ResponseRef{Ref: "#/components/responses/Error"}
+	if _, ok := g.Schema.Definitions[capabilityStatementName]; ok {
+		param := NewParameterWithSchema(InQuery, "mode", false, NewSchemaEnum(capabilitiesModes...))
+		param.Value.Description = "The kind of the capability statement: full, normal or terminology capabilities."
+		g.Swagger.Paths["/metadata"] = &openapi3.PathItem{Get: &openapi3.Operation{
+			Description: "The capabilities interaction retrieves the capability statement of the server.",
+			Tags:        []string{metadataTag},
+			Parameters:  openapi3.Parameters{param},
+			Security:    &openapi3.SecurityRequirements{},
+			Responses: openapi3.Responses{
+				"200": &openapi3.ResponseRef{Value: &openapi3.Response{
+					Description: ptr.String("OK"),
+					Content:     openapi3.NewContentWithJSONSchemaRef(g.schemaRef(capabilityStatementName)),
+				}},
+				"400": respErr,
+				"404": respErr,
+			},
+		}}
+	}
+	if g.SMART {
+		err := g.addComponentSchema(smartConfigurationName, discoverySchema(
+			"SMART App Launch configuration.", smartConfigurationProperties, "token_endpoint", "capabilities"))
+		if err != nil {
+			return err
+		}
+		g.Swagger.Paths["/.well-known/smart-configuration"] = g.discoveryPathItem(
+			"The SMART App Launch configuration of the authorization endpoints and capabilities of the server.", smartConfigurationName)
+	}
+	if g.UDAP {
+		err := g.addComponentSchema(udapMetadataName, discoverySchema(
+			"UDAP server metadata.", udapMetadataProperties, "udap_versions_supported"))
+		if err != nil {
+			return err
+		}
+		g.Swagger.Paths["/.well-known/udap"] = g.discoveryPathItem(
+			"The UDAP server metadata of the supported versions, profiles and endpoints of the server.", udapMetadataName)
+	}
+	return nil
+}
+
+// discoverySchema returns the schema of the discovery document with the properties by types.
+func discoverySchema(description string, properties map[string][]string, required ...string) *openapi3.Schema {
+	schema := &openapi3.Schema{
+		Type:        "object",
+		Description: description,
+		Properties:  make(openapi3.Schemas),
+		Required:    required,
+	}
+	for typ, names := range properties {
+		for _, name := range names {
+			switch typ {
+			case "uri":
+				schema.Properties[name] = openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string", Format: "uri"})
+			case "array":
+				schema.Properties[name] = openapi3.NewSchemaRef("", &openapi3.Schema{Type: "array", Items: NewSchemaString()})
+			default:
+				schema.Properties[name] = NewSchemaString()
+			}
+		}
+	}
+	return schema
+}
+
+// discoveryPathItem returns the unauthenticated path item of the discovery document.
+func (g *Generator) discoveryPathItem(description, schema string) *openapi3.PathItem {
+	return &openapi3.PathItem{Get: &openapi3.Operation{
+		Description: description,
+		Tags:        []string{metadataTag},
+		Security:    &openapi3.SecurityRequirements{},
+		Responses: openapi3.Responses{
+			"200": &openapi3.ResponseRef{Value: &openapi3.Response{
+				Description: ptr.String("OK"),
+				Content:     openapi3.NewContentWithJSONSchemaRef(g.schemaRef(schema)),
+			}},
+			"404": &openapi3.ResponseRef{Value: &openapi3.Response{Description: ptr.String("Not Found")}},
+		},
+	}}
+}